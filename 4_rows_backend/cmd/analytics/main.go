@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -94,6 +95,7 @@ func main() {
 
 func startAPIServer(port string) {
 	http.HandleFunc("/api/leaderboard", handleLeaderboard)
+	http.HandleFunc("GET /api/ratings/{id}", handleRating)
 	http.HandleFunc("/api/stats", handleStats)
 	http.HandleFunc("/api/health", handleHealth)
 
@@ -111,7 +113,18 @@ func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	leaderboard, err := storage.GetLeaderboard(10)
+	// ?sort=rating ranks by rating instead of wins; ?min_games=N sets how many
+	// games (rated games when sorting by rating) a player needs to be listed
+	opts := analytics.LeaderboardOptions{Limit: 10, SortBy: analytics.SortByWins}
+	if r.URL.Query().Get("sort") == analytics.SortByRating {
+		opts.SortBy = analytics.SortByRating
+		opts.MinGames = analytics.DefaultMinRatedGames
+	}
+	if minGames, err := strconv.Atoi(r.URL.Query().Get("min_games")); err == nil && minGames >= 0 {
+		opts.MinGames = minGames
+	}
+
+	leaderboard, err := storage.GetLeaderboard(opts)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -122,6 +135,33 @@ func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func handleRating(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	id := r.PathValue("id")
+	player, err := storage.GetPlayerRating(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if player == nil {
+		http.Error(w, "player has no rated games", http.StatusNotFound)
+		return
+	}
+
+	history, err := storage.GetRatingHistory(id, 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"player":  player,
+		"history": history,
+	})
+}
+
 func handleStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -0,0 +1,66 @@
+// Command analyze prints the bot's analysis of a position, given either in
+// position notation or as a move sequence from the variant's start.
+//
+//	go run ./cmd/analyze -moves 4453
+//	go run ./cmd/analyze -position "7/7/7/7/3o3/3x3 1 classic 4 0-0"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"4_rows_backend/internal/bot"
+	"4_rows_backend/internal/game"
+)
+
+func main() {
+	position := flag.String("position", "", "position in position notation")
+	moves := flag.String("moves", "", "move sequence played from the starting position")
+	variant := flag.String("variant", "classic", "variant the move sequence is played in")
+	budget := flag.Duration("budget", 2*time.Second, "time the bot may spend on the analysis")
+	flag.Parse()
+
+	pos, err := readPosition(*position, *moves, *variant)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	state := pos.RuleState()
+	analysis := bot.Analyze(&pos.Board, &state, game.RulesFor(pos.Variant), pos.ToMove, *budget)
+
+	fmt.Println(pos)
+	fmt.Printf("best move: %s (exact: %v)\n", game.FormatMoves([]game.Move{{Kind: analysis.BestAction, Column: analysis.BestMove}}), analysis.Exact)
+	printColumns("drop", analysis.Columns)
+	printColumns("pop", analysis.Pops)
+}
+
+func readPosition(position, moves, variant string) (game.Position, error) {
+	if position != "" {
+		return game.ParsePosition(position)
+	}
+	v, err := game.ParseVariant(variant)
+	if err != nil {
+		return game.Position{}, err
+	}
+	parsed, err := game.ParseMoves(moves)
+	if err != nil {
+		return game.Position{}, err
+	}
+	pos, _, err := game.ReplayMoves(v, game.Dimensions{}, parsed)
+	return pos, err
+}
+
+func printColumns(action string, columns []bot.ColumnAnalysis) {
+	for _, column := range columns {
+		if !column.Playable {
+			continue
+		}
+		fmt.Printf("%-4s %2d  %-7s score %5d", action, column.Column+1, column.Outcome, column.Score)
+		if column.MovesLeft > 0 {
+			fmt.Printf("  in %d", column.MovesLeft)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,110 @@
+// Command bookgen solves every position up to a given number of discs and
+// writes the results as an opening book for the bot.
+//
+//	go run ./cmd/bookgen -depth 6 -out opening_book.bin
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"4_rows_backend/internal/solver"
+)
+
+func main() {
+	depth := flag.Int("depth", 6, "solve positions with up to this many discs")
+	out := flag.String("out", "opening_book.bin", "output file")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of positions solved in parallel")
+	tableSize := flag.Int("table", solver.DefaultTableSize, "transposition table entries per worker (a prime)")
+	weak := flag.Bool("weak", false, "only store win/draw/loss instead of exact scores")
+	flag.Parse()
+
+	positions := enumerate(*depth)
+	log.Printf("Solving %d positions with up to %d discs using %d workers", len(positions), *depth, *workers)
+
+	entries := make([]solver.BookEntry, len(positions))
+	jobs := make(chan int)
+	var done atomic.Int64
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := solver.NewWithTableSize(*tableSize)
+			for i := range jobs {
+				p := positions[i]
+				entries[i] = solver.BookEntry{
+					Key:   p.CanonicalKey(),
+					Score: int8(s.Solve(p, *weak)),
+				}
+
+				if n := done.Add(1); n%500 == 0 {
+					log.Printf("Solved %d/%d positions (%s)", n, len(positions), time.Since(started).Round(time.Second))
+				}
+			}
+		}()
+	}
+
+	// Queue the deepest positions first: they solve quickly and leave entries in
+	// each worker's table that speed up the slower, shallower ones
+	for i := len(positions) - 1; i >= 0; i-- {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	book := solver.NewBook(*depth, entries)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	defer f.Close()
+
+	n, err := book.WriteTo(f)
+	if err != nil {
+		log.Fatalf("Failed to write book: %v", err)
+	}
+
+	log.Printf("Wrote %d positions (%d bytes) to %s in %s", book.Len(), n, *out, time.Since(started).Round(time.Second))
+}
+
+// enumerate returns every reachable, undecided position with up to depth discs,
+// keeping one of each pair of mirror images
+func enumerate(depth int) []solver.Position {
+	seen := make(map[uint64]bool)
+	level := []solver.Position{{}}
+	all := []solver.Position{{}}
+	seen[0] = true
+
+	for d := 0; d < depth; d++ {
+		var next []solver.Position
+		for _, p := range level {
+			for col := 0; col < solver.Width; col++ {
+				if !p.CanPlay(col) || p.IsWinningMove(col) {
+					continue
+				}
+
+				child := p
+				child.Play(col)
+				key := child.CanonicalKey()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				next = append(next, child)
+			}
+		}
+		all = append(all, next...)
+		level = next
+	}
+
+	return all
+}
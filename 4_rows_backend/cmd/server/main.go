@@ -4,15 +4,76 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"4_rows_backend/internal/accounts"
+	"4_rows_backend/internal/analytics"
+	"4_rows_backend/internal/bot"
+	"4_rows_backend/internal/chat"
 	"4_rows_backend/internal/events"
 	"4_rows_backend/internal/game"
+	"4_rows_backend/internal/reconnect"
 	"4_rows_backend/internal/storage"
+	"4_rows_backend/internal/transport/api"
 	ws "4_rows_backend/internal/transport/websocket"
 )
 
 func main() {
+	// Initialize reconnect manager (resume tokens are signed with RESUME_SECRET)
+	resumeSecret := getEnv("RESUME_SECRET", "")
+	if resumeSecret == "" {
+		log.Println("Warning: RESUME_SECRET not set, resume tokens will not survive a restart")
+	}
+	grace, err := time.ParseDuration(getEnv("RECONNECT_GRACE", "60s"))
+	if err != nil {
+		log.Printf("Warning: invalid RECONNECT_GRACE, using default: %v", err)
+		grace = reconnect.DefaultGracePeriod
+	}
+	reconnect.NewManager([]byte(resumeSecret), grace)
+	log.Printf("Reconnect grace period: %s", grace)
+
+	// Guest tokens are signed with GUEST_SECRET
+	guestSecret := getEnv("GUEST_SECRET", "")
+	if guestSecret == "" {
+		log.Println("Warning: GUEST_SECRET not set, guests will get new IDs after a restart")
+	}
+	accounts.SetGuestSecret([]byte(guestSecret))
+
+	// Initialize chat (CHAT_BANNED_WORDS is a comma separated list of words to mask)
+	chatConfig := chat.Config{SpectatorChannel: getEnv("CHAT_SPECTATOR_CHANNEL", "false") == "true"}
+	if words := getEnv("CHAT_BANNED_WORDS", ""); words != "" {
+		chatConfig.BannedWords = strings.Split(words, ",")
+	}
+	if chatConfig.RateLimit, err = strconv.Atoi(getEnv("CHAT_RATE_LIMIT", strconv.Itoa(chat.DefaultRateLimit))); err != nil {
+		log.Printf("Warning: invalid CHAT_RATE_LIMIT, using default: %v", err)
+	}
+	if chatConfig.RateWindow, err = time.ParseDuration(getEnv("CHAT_RATE_WINDOW", chat.DefaultRateWindow.String())); err != nil {
+		log.Printf("Warning: invalid CHAT_RATE_WINDOW, using default: %v", err)
+	}
+	chat.NewManager(chatConfig)
+	log.Printf("Chat limited to %d messages per %s", chat.GetManager().RateLimit(), chat.GetManager().RateWindow())
+
+	// Players who run out of time lose; tell their room when a flag falls
+	game.GetRoomManager().SetTimeoutHandler(ws.HandleClockTimeout)
+
+	// Players left unmatched for MATCH_BOT_FALLBACK play the bot instead ("0s" disables it)
+	fallback, err := time.ParseDuration(getEnv("MATCH_BOT_FALLBACK", game.DefaultBotFallback.String()))
+	if err != nil {
+		log.Printf("Warning: invalid MATCH_BOT_FALLBACK, using default: %v", err)
+		fallback = game.DefaultBotFallback
+	}
+	game.GetMatchmaker().SetMatchHandler(ws.HandleMatch)
+	game.GetMatchmaker().SetBotFallback(fallback, ws.HandleMatchFallback)
+	if analyticsURL := getEnv("ANALYTICS_URL", ""); analyticsURL != "" {
+		game.GetMatchmaker().SetRatingLookup(analytics.NewRatingClient(analyticsURL).Lookup)
+		log.Printf("Matchmaking by rating from %s", analyticsURL)
+	}
+
+	// Keep lobby subscribers up to date as public rooms open and fill
+	game.GetRoomManager().SetLobbyHandler(ws.HandleLobbyUpdate)
+
 	// Initialize SQLite storage
 	store, err := storage.NewSQLiteStorage("game.db")
 	if err != nil {
@@ -24,11 +85,35 @@ func main() {
 		// Set storage on RoomManager
 		game.GetRoomManager().SetStorage(store)
 
+		// Player accounts need storage; without it everyone plays as a guest
+		sessionTTL, err := time.ParseDuration(getEnv("SESSION_TTL", accounts.DefaultSessionTTL.String()))
+		if err != nil {
+			log.Printf("Warning: invalid SESSION_TTL, using default: %v", err)
+			sessionTTL = accounts.DefaultSessionTTL
+		}
+		accounts.NewManager(store, sessionTTL)
+
+		// Restore unfinished rooms so players can resume after a restart
+		restored, err := game.GetRoomManager().RestoreRooms()
+		if err != nil {
+			log.Printf("Warning: Could not restore rooms from SQLite: %v", err)
+		} else {
+			ws.HoldRestoredRooms(restored)
+			log.Printf("Restored %d unfinished rooms from SQLite", len(restored))
+		}
+
 		// Start cleanup routine: every 5 minutes, delete games inactive for 2 hours
 		store.StartCleanupRoutine(5*time.Minute, 2*time.Hour)
 		log.Println("Cleanup routine started (checking every 5 minutes, removing games inactive for 2 hours)")
 	}
 
+	// Load the bot's opening book (generate one with cmd/bookgen)
+	bookPath := getEnv("OPENING_BOOK", "opening_book.bin")
+	if err := bot.LoadOpeningBook(bookPath); err != nil {
+		log.Printf("Warning: Could not load opening book %s: %v", bookPath, err)
+		log.Println("Bot will search early moves instead of using the book")
+	}
+
 	// Initialize Kafka producer (optional - falls back gracefully if Kafka not available)
 	kafkaBrokers := getEnv("KAFKA_BROKERS", "127.0.0.1:9094")
 	kafkaTopic := getEnv("KAFKA_TOPIC", "game-events")
@@ -36,7 +121,24 @@ func main() {
 	producer := events.NewKafkaProducer([]string{kafkaBrokers}, kafkaTopic)
 	defer producer.Close()
 
+	// Guest upgrades are kept until analytics has them; send the ones left over and keep retrying
+	if am := accounts.GetManager(); am != nil {
+		if err := am.PublishUpgrades(api.PublishAccountUpgraded); err != nil {
+			log.Printf("Warning: guest upgrades not published yet: %v", err)
+		}
+		am.StartUpgradePublisher(time.Minute, api.PublishAccountUpgraded)
+	}
+
 	http.HandleFunc("/ws", ws.HandleWS)
+	http.HandleFunc("GET /api/games/{id}", api.HandleGetGame)
+	http.HandleFunc("GET /api/rooms", api.HandleListRooms)
+	http.HandleFunc("GET /api/rooms/{code}/moves", api.HandleGetRoomMoves)
+	http.HandleFunc("GET /api/matchmaking", api.HandleMatchmakingStats)
+	http.HandleFunc("POST /api/accounts", api.HandleRegister)
+	http.HandleFunc("POST /api/login", api.HandleLogin)
+	http.HandleFunc("POST /api/logout", api.HandleLogout)
+	http.HandleFunc("GET /api/me", api.HandleMe)
+	http.HandleFunc("OPTIONS /api/", api.HandlePreflight)
 
 	port := ":8080"
 	log.Printf("server running on %s", port)
@@ -0,0 +1,245 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"4_rows_backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSessionTTL is how long a login stays valid
+	DefaultSessionTTL = 30 * 24 * time.Hour
+
+	MinUsernameLength = 3
+	MaxUsernameLength = 20
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
+var (
+	ErrInvalidUsername    = errors.New("username must be 3 to 20 letters, digits, '_' or '-'")
+	ErrUsernameTaken      = storage.ErrUsernameTaken
+	ErrGuestUpgraded      = storage.ErrGuestUpgraded
+	ErrInvalidPassword    = errors.New("password must be 8 to 128 characters")
+	ErrInvalidCredentials = errors.New("wrong username or password")
+	ErrInvalidSession     = errors.New("invalid or expired session")
+)
+
+// Account is a registered player. The ID never changes and is what games and
+// statistics are attributed to; the username is unique ignoring case.
+type Account struct {
+	ID        string
+	Username  string
+	GuestID   string // the guest the account was upgraded from, if any
+	CreatedAt time.Time
+}
+
+// Manager registers accounts and issues and checks session tokens
+type Manager struct {
+	store *storage.SQLiteStorage
+	ttl   time.Duration
+	dummy string // hash checked when a username is unknown, so logins take the same time either way
+}
+
+var manager *Manager
+
+// NewManager creates the global account manager, keeping accounts in store
+func NewManager(store *storage.SQLiteStorage, ttl time.Duration) *Manager {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	dummy, _ := hashPassword("")
+
+	manager = &Manager{store: store, ttl: ttl, dummy: dummy}
+	return manager
+}
+
+// GetManager returns the global account manager, or nil when accounts are
+// unavailable because the server runs without storage
+func GetManager() *Manager {
+	return manager
+}
+
+// Register creates an account and logs it in, returning the session token.
+// Given a guest ID, the guest is upgraded: the account takes over the guest's
+// archived games.
+func (m *Manager) Register(username, password, guestID string) (*Account, string, error) {
+	username = strings.TrimSpace(username)
+	if err := validateUsername(username); err != nil {
+		return nil, "", err
+	}
+	if n := utf8.RuneCountInString(password); n < MinPasswordLength || n > MaxPasswordLength {
+		return nil, "", ErrInvalidPassword
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, "", err
+	}
+	data := &storage.AccountData{
+		ID:           uuid.NewString(),
+		Username:     username,
+		PasswordHash: hash,
+		GuestID:      guestID,
+		CreatedAt:    time.Now(),
+	}
+	if err := m.store.CreateAccount(data); err != nil {
+		return nil, "", err
+	}
+
+	account := newAccount(data)
+	token, err := m.createSession(account.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return account, token, nil
+}
+
+// Login checks a username and password, returning the account and a new session token
+func (m *Manager) Login(username, password string) (*Account, string, error) {
+	data, err := m.store.GetAccountByUsername(strings.TrimSpace(username))
+	if err != nil {
+		return nil, "", err
+	}
+	if data == nil {
+		checkPassword(m.dummy, password)
+		return nil, "", ErrInvalidCredentials
+	}
+	if !checkPassword(data.PasswordHash, password) {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	account := newAccount(data)
+	token, err := m.createSession(account.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return account, token, nil
+}
+
+// Authenticate returns the account a session token belongs to
+func (m *Manager) Authenticate(token string) (*Account, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+	session, err := m.store.GetSession(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	data, err := m.store.GetAccount(session.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrInvalidSession
+	}
+	return newAccount(data), nil
+}
+
+// Logout ends the session of a token
+func (m *Manager) Logout(token string) error {
+	return m.store.DeleteSession(hashToken(token))
+}
+
+// PublishUpgrades hands every guest upgrade analytics has not been told about
+// to publish, oldest first, and remembers the ones it accepts. It stops at the
+// first failure; the rest are kept for the next call.
+func (m *Manager) PublishUpgrades(publish func(account *Account) error) error {
+	pending, err := m.store.GetUnpublishedUpgrades()
+	if err != nil {
+		return err
+	}
+	for i := range pending {
+		if err := publish(newAccount(&pending[i])); err != nil {
+			return err
+		}
+		if err := m.store.MarkUpgradePublished(pending[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartUpgradePublisher retries unpublished guest upgrades every interval,
+// so none is lost while the event stream is down
+func (m *Manager) StartUpgradePublisher(interval time.Duration, publish func(account *Account) error) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if err := m.PublishUpgrades(publish); err != nil {
+				log.Printf("Guest upgrades not published yet: %v", err)
+			}
+		}
+	}()
+}
+
+func (m *Manager) createSession(accountID string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	err := m.store.CreateSession(&storage.SessionData{
+		TokenHash: hashToken(token),
+		AccountID: accountID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashToken is how session tokens are stored; they are random, so a plain hash is enough
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return ErrInvalidUsername
+	}
+	for _, ch := range username {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '-') {
+			return ErrInvalidUsername
+		}
+	}
+	// The bot plays under this name
+	if strings.EqualFold(username, "bot") {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+func newAccount(data *storage.AccountData) *Account {
+	return &Account{ID: data.ID, Username: data.Username, GuestID: data.GuestID, CreatedAt: data.CreatedAt}
+}
+
+// TokenFromRequest returns the session token sent with a request, either as a
+// bearer Authorization header or, for WebSocket upgrades which browsers cannot
+// add headers to, as the token query parameter
+func TokenFromRequest(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("token")
+}
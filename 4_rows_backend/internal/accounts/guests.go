@@ -0,0 +1,108 @@
+package accounts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Guests play without an account but keep one ID across connections, so their
+// games are attributed consistently. The ID is proven by a signed guest token
+// the client keeps in a cookie or local storage.
+const (
+	GuestPrefix    = "guest-"
+	GuestCookie    = "guest_token"
+	GuestCookieAge = 365 * 24 * time.Hour
+)
+
+var ErrInvalidGuestToken = errors.New("invalid guest token")
+
+var guestSecret = randomSecret()
+
+// SetGuestSecret sets the key guest tokens are signed with. Without one a
+// random key is used, and guests get new IDs after a restart.
+func SetGuestSecret(secret []byte) {
+	if len(secret) > 0 {
+		guestSecret = secret
+	}
+}
+
+// NewGuest returns a new guest ID and its token
+func NewGuest() (string, string) {
+	id := GuestPrefix + uuid.NewString()
+	return id, GuestToken(id)
+}
+
+// GuestToken returns the token proving a guest ID
+func GuestToken(id string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(id))
+	return encoded + "." + signGuest(encoded)
+}
+
+// ParseGuestToken verifies a guest token and returns the guest ID
+func ParseGuestToken(token string) (string, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(signGuest(encoded))) {
+		return "", ErrInvalidGuestToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !IsGuest(string(raw)) {
+		return "", ErrInvalidGuestToken
+	}
+	return string(raw), nil
+}
+
+// IsGuest reports whether a player ID belongs to a guest rather than an account
+func IsGuest(id string) bool {
+	return strings.HasPrefix(id, GuestPrefix)
+}
+
+// GuestTokenFromRequest returns the guest token sent as the guest query
+// parameter or, failing that, the guest cookie
+func GuestTokenFromRequest(r *http.Request) string {
+	if token := r.URL.Query().Get("guest"); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(GuestCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// GuestCookieFor returns the cookie that keeps a guest token in the browser.
+// An empty token returns a cookie that removes it.
+func GuestCookieFor(token string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     GuestCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(GuestCookieAge / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if token == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+func signGuest(encoded string) string {
+	mac := hmac.New(sha256.New, guestSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
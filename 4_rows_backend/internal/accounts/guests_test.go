@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGuestTokenRoundTrip(t *testing.T) {
+	id, token := NewGuest()
+	if !IsGuest(id) {
+		t.Fatalf("guest ID %q lacks the guest prefix", id)
+	}
+	got, err := ParseGuestToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("ParseGuestToken = %q, want %q", got, id)
+	}
+}
+
+func TestParseGuestTokenErrors(t *testing.T) {
+	_, token := NewGuest()
+	encoded, sig, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(GuestPrefix + "someone-else"))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", encoded},
+		{"tampered signature", encoded + "." + strings.ToUpper(sig)},
+		{"other ID", forged + "." + sig},
+		{"account ID", GuestToken("account-1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseGuestToken(tt.token); !errors.Is(err, ErrInvalidGuestToken) {
+				t.Errorf("error = %v, want ErrInvalidGuestToken", err)
+			}
+		})
+	}
+}
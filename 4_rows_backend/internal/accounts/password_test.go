@@ -0,0 +1,55 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256, the first two from RFC 7914
+	tests := []struct {
+		password, salt string
+		iterations     int
+		key            string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for _, tt := range tests {
+		want, _ := hex.DecodeString(tt.key)
+		got := pbkdf2([]byte(tt.password), []byte(tt.salt), tt.iterations, len(want))
+		if hex.EncodeToString(got) != tt.key {
+			t.Errorf("pbkdf2(%q, %q, %d) = %x, want %s", tt.password, tt.salt, tt.iterations, got, tt.key)
+		}
+	}
+}
+
+func TestPasswordRoundTrip(t *testing.T) {
+	encoded, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkPassword(encoded, "correct horse") {
+		t.Error("checkPassword rejected the right password")
+	}
+	if checkPassword(encoded, "correct horse ") {
+		t.Error("checkPassword accepted a wrong password")
+	}
+
+	other, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == encoded {
+		t.Error("two hashes of the same password share a salt")
+	}
+
+	for _, bad := range []string{"", "md5$1$c2FsdA$a2V5", "pbkdf2-sha256$0$c2FsdA$a2V5", "pbkdf2-sha256$1$!$a2V5", "pbkdf2-sha256$1$c2FsdA$"} {
+		if checkPassword(bad, "correct horse") {
+			t.Errorf("checkPassword accepted malformed hash %q", bad)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RatingClient reads player ratings from the analytics service's HTTP API
+type RatingClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewRatingClient returns a client for the analytics service at baseURL, e.g. http://localhost:8081
+func NewRatingClient(baseURL string) *RatingClient {
+	return &RatingClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Lookup returns a player's rating by account or guest ID, reporting false if
+// they have none or the service is unreachable
+func (c *RatingClient) Lookup(playerID string) (float64, bool) {
+	resp, err := c.http.Get(c.baseURL + "/api/ratings/" + url.PathEscape(playerID))
+	if err != nil {
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+
+	var body struct {
+		Player PlayerRating `json:"player"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, false
+	}
+	return body.Player.Rating.Rating, true
+}
@@ -0,0 +1,103 @@
+package analytics
+
+import "math"
+
+// Glicko-2 constants, see http://www.glicko.net/glicko/glicko2.pdf
+const (
+	DefaultRating     = 1500
+	DefaultDeviation  = 350
+	DefaultVolatility = 0.06
+
+	glickoScale = 173.7178
+	glickoTau   = 0.5 // constrains how fast volatility changes
+	glickoEps   = 0.000001
+)
+
+// Rating is a player's Glicko-2 rating on the familiar Elo-like scale
+type Rating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"` // RD: how uncertain the rating is
+	Volatility float64 `json:"volatility"`
+}
+
+// NewRating returns the rating of a player who has not played yet
+func NewRating() Rating {
+	return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// Update returns r after a single game against opponent, score being 1 for a
+// win, 0.5 for a draw and 0 for a loss. Every game is its own rating period.
+func (r Rating) Update(opponent Rating, score float64) Rating {
+	return r.updatePeriod([]glickoResult{{opponent: opponent, score: score}})
+}
+
+// glickoResult is one game of a rating period
+type glickoResult struct {
+	opponent Rating
+	score    float64
+}
+
+// updatePeriod returns r after all the games of one rating period
+func (r Rating) updatePeriod(results []glickoResult) Rating {
+	mu := (r.Rating - DefaultRating) / glickoScale
+	phi := r.Deviation / glickoScale
+
+	var vInv, sum float64
+	for _, result := range results {
+		muJ := (result.opponent.Rating - DefaultRating) / glickoScale
+		phiJ := result.opponent.Deviation / glickoScale
+
+		g := 1 / math.Sqrt(1+3*phiJ*phiJ/(math.Pi*math.Pi))
+		expected := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		vInv += g * g * expected * (1 - expected)
+		sum += g * (result.score - expected)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	sigma := newVolatility(phi, r.Volatility, v, delta)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*sum
+
+	return Rating{
+		Rating:     newMu*glickoScale + DefaultRating,
+		Deviation:  newPhi * glickoScale,
+		Volatility: sigma,
+	}
+}
+
+// newVolatility solves for the new volatility with the Illinois algorithm (step 5 of the paper)
+func newVolatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		B = a - k*glickoTau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEps {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
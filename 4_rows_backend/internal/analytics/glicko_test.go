@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGlickoReferenceExample(t *testing.T) {
+	// The worked example of the Glicko-2 paper: one rating period with three games
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	got := player.updatePeriod([]glickoResult{
+		{opponent: Rating{Rating: 1400, Deviation: 30}, score: 1},
+		{opponent: Rating{Rating: 1550, Deviation: 100}, score: 0},
+		{opponent: Rating{Rating: 1700, Deviation: 300}, score: 0},
+	})
+
+	want := Rating{Rating: 1464.06, Deviation: 151.52, Volatility: 0.05999}
+	if math.Abs(got.Rating-want.Rating) > 0.01 ||
+		math.Abs(got.Deviation-want.Deviation) > 0.01 ||
+		math.Abs(got.Volatility-want.Volatility) > 0.00001 {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGlickoUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		score  float64
+		rising bool
+	}{
+		{"win", 1, true},
+		{"loss", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRating()
+			got := r.Update(NewRating(), tt.score)
+			if (got.Rating > r.Rating) != tt.rising {
+				t.Errorf("rating went from %.1f to %.1f", r.Rating, got.Rating)
+			}
+			if got.Deviation >= r.Deviation {
+				t.Errorf("deviation grew from %.1f to %.1f", r.Deviation, got.Deviation)
+			}
+		})
+	}
+
+	// A draw between equal players leaves the rating where it was
+	r := NewRating()
+	if got := r.Update(NewRating(), 0.5); math.Abs(got.Rating-r.Rating) > 1e-9 {
+		t.Errorf("draw moved the rating to %.4f", got.Rating)
+	}
+}
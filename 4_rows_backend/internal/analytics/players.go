@@ -0,0 +1,72 @@
+package analytics
+
+import "database/sql"
+
+// UpgradeGuest attributes everything a guest played to the account they
+// registered. Games that finish later under the guest ID are attributed to
+// the account as they arrive. An account that somehow has a rating already
+// keeps it; otherwise it takes over the guest's.
+func (s *AnalyticsStorage) UpgradeGuest(guestID, accountID, username string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO player_aliases (guest_id, account_id) VALUES (?, ?)",
+		guestID, accountID,
+	)
+	if err != nil {
+		return err
+	}
+
+	updates := []string{
+		"UPDATE game_events SET player1_id = ? WHERE player1_id = ?",
+		"UPDATE game_events SET player2_id = ? WHERE player2_id = ?",
+		"UPDATE rating_history SET player_id = ? WHERE player_id = ?",
+		"UPDATE rating_history SET opponent_id = ? WHERE opponent_id = ?",
+	}
+	for _, query := range updates {
+		if _, err := tx.Exec(query, accountID, guestID); err != nil {
+			return err
+		}
+	}
+
+	var rated int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM player_ratings WHERE player_id = ?", accountID).Scan(&rated); err != nil {
+		return err
+	}
+	if rated > 0 {
+		_, err = tx.Exec("DELETE FROM player_ratings WHERE player_id = ?", guestID)
+	} else {
+		_, err = tx.Exec(
+			"UPDATE player_ratings SET player_id = ?, player_name = ? WHERE player_id = ?",
+			accountID, username, guestID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resolvePlayers replaces the IDs of upgraded guests in an event with their accounts
+func (s *AnalyticsStorage) resolvePlayers(event *GameEvent) error {
+	for _, id := range []*string{&event.Player1ID, &event.Player2ID} {
+		if *id == "" {
+			continue
+		}
+		var accountID string
+		err := s.db.QueryRow("SELECT account_id FROM player_aliases WHERE guest_id = ?", *id).Scan(&accountID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*id = accountID
+	}
+	return nil
+}
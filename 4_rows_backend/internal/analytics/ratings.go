@@ -0,0 +1,144 @@
+package analytics
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PlayerRating is a player's current rating and how many rated games it is based on
+type PlayerRating struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"` // name in the player's latest rated game
+	Games int    `json:"games"`
+	Rating
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RatingChange is one game's entry in a player's rating history
+type RatingChange struct {
+	RoomCode     string    `json:"room_code"`
+	OpponentID   string    `json:"opponent_id"`
+	Opponent     string    `json:"opponent"`
+	Score        float64   `json:"score"` // 1 win, 0.5 draw, 0 loss
+	RatingBefore float64   `json:"rating_before"`
+	Rating       float64   `json:"rating"`
+	Deviation    float64   `json:"deviation"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// rated reports whether a game counts for ratings: a rated room between two
+// different players with IDs. Casual rooms allow hints and takebacks.
+func (e *GameEvent) rated() bool {
+	return e.Rated && !e.IsBotGame && e.Player1ID != "" && e.Player2ID != "" && e.Player1ID != e.Player2ID
+}
+
+// updateRatings applies the result of a game to both players' ratings and records the change
+func (s *AnalyticsStorage) updateRatings(event *GameEvent) error {
+	if !event.rated() {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p1, err := getRating(tx, event.Player1ID, event.Player1Name)
+	if err != nil {
+		return err
+	}
+	p2, err := getRating(tx, event.Player2ID, event.Player2Name)
+	if err != nil {
+		return err
+	}
+
+	score := 0.5
+	switch event.Winner {
+	case 1:
+		score = 1
+	case 2:
+		score = 0
+	}
+
+	updated1 := p1.Rating.Update(p2.Rating, score)
+	updated2 := p2.Rating.Update(p1.Rating, 1-score)
+	if err := saveRating(tx, event, p1, updated1, p2, score); err != nil {
+		return err
+	}
+	if err := saveRating(tx, event, p2, updated2, p1, 1-score); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func getRating(tx *sql.Tx, id, name string) (PlayerRating, error) {
+	player := PlayerRating{ID: id, Name: name, Rating: NewRating()}
+	err := tx.QueryRow(
+		"SELECT rating, deviation, volatility, games FROM player_ratings WHERE player_id = ?", id,
+	).Scan(&player.Rating.Rating, &player.Deviation, &player.Volatility, &player.Games)
+	if err == sql.ErrNoRows {
+		return player, nil
+	}
+	return player, err
+}
+
+func saveRating(tx *sql.Tx, event *GameEvent, before PlayerRating, after Rating, opponent PlayerRating, score float64) error {
+	_, err := tx.Exec(`
+	INSERT INTO player_ratings (player_id, player_name, rating, deviation, volatility, games, updated_at)
+	VALUES (?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP)
+	ON CONFLICT(player_id) DO UPDATE SET
+		player_name = excluded.player_name,
+		rating = excluded.rating,
+		deviation = excluded.deviation,
+		volatility = excluded.volatility,
+		games = games + 1,
+		updated_at = CURRENT_TIMESTAMP
+	`, before.ID, before.Name, after.Rating, after.Deviation, after.Volatility)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+	INSERT INTO rating_history (player_id, room_code, opponent_id, opponent_name, score, rating_before, rating, deviation, volatility)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, before.ID, event.RoomCode, opponent.ID, opponent.Name, score, before.Rating.Rating, after.Rating, after.Deviation, after.Volatility)
+	return err
+}
+
+// GetPlayerRating returns a player's rating by account ID, or nil if they have no rated games
+func (s *AnalyticsStorage) GetPlayerRating(id string) (*PlayerRating, error) {
+	player := PlayerRating{ID: id}
+	err := s.db.QueryRow(
+		"SELECT player_name, rating, deviation, volatility, games, updated_at FROM player_ratings WHERE player_id = ?", id,
+	).Scan(&player.Name, &player.Rating.Rating, &player.Deviation, &player.Volatility, &player.Games, &player.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+// GetRatingHistory returns a player's most recent rating changes, newest first
+func (s *AnalyticsStorage) GetRatingHistory(id string, limit int) ([]RatingChange, error) {
+	rows, err := s.db.Query(`
+	SELECT room_code, opponent_id, opponent_name, score, rating_before, rating, deviation, created_at
+	FROM rating_history WHERE player_id = ? ORDER BY id DESC LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []RatingChange
+	for rows.Next() {
+		var change RatingChange
+		if err := rows.Scan(&change.RoomCode, &change.OpponentID, &change.Opponent, &change.Score, &change.RatingBefore, &change.Rating, &change.Deviation, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
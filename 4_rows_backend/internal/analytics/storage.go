@@ -3,6 +3,7 @@ package analytics
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -15,8 +16,12 @@ type GameEvent struct {
 	RoomCode        string
 	Player1Name     string
 	Player2Name     string
+	Player1ID       string // account or guest IDs, empty in older events
+	Player2ID       string
 	Winner          int
+	Reason          string // how the game ended, empty in older events
 	IsBotGame       bool
+	Rated           bool
 	DurationSeconds int64
 	CreatedAt       time.Time
 }
@@ -78,24 +83,86 @@ func createAnalyticsTables(db *sql.DB) error {
 		draws INTEGER DEFAULT 0,
 		avg_duration_seconds REAL DEFAULT 0
 	);
+
+	CREATE TABLE IF NOT EXISTS player_ratings (
+		player_id TEXT PRIMARY KEY,
+		player_name TEXT NOT NULL,
+		rating REAL NOT NULL,
+		deviation REAL NOT NULL,
+		volatility REAL NOT NULL,
+		games INTEGER DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS rating_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		player_id TEXT NOT NULL,
+		room_code TEXT NOT NULL,
+		opponent_id TEXT NOT NULL,
+		opponent_name TEXT NOT NULL,
+		score REAL NOT NULL,
+		rating_before REAL NOT NULL,
+		rating REAL NOT NULL,
+		deviation REAL NOT NULL,
+		volatility REAL NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_rating_history_player ON rating_history(player_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS player_aliases (
+		guest_id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
 	`
-	_, err := db.Exec(query)
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	// Events from before player IDs have none and are ranked by player name
+	for _, column := range []string{"player1_id", "player2_id"} {
+		if err := addColumnIfMissing(db, "game_events", column, "TEXT DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	if err := addColumnIfMissing(db, "game_events", "reason", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return addColumnIfMissing(db, "game_events", "rated", "INTEGER DEFAULT 0")
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
 	return err
 }
 
 // SaveGameEvent stores a game event
 func (s *AnalyticsStorage) SaveGameEvent(event *GameEvent) error {
+	if err := s.resolvePlayers(event); err != nil {
+		return err
+	}
+
 	query := `
-	INSERT INTO game_events (room_code, player1_name, player2_name, winner, is_bot_game, duration_seconds)
-	VALUES (?, ?, ?, ?, ?, ?)
+	INSERT INTO game_events (room_code, player1_name, player2_name, player1_id, player2_id, winner, reason, is_bot_game, rated, duration_seconds)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(query,
 		event.RoomCode,
 		event.Player1Name,
 		event.Player2Name,
+		event.Player1ID,
+		event.Player2ID,
 		event.Winner,
+		event.Reason,
 		boolToInt(event.IsBotGame),
+		boolToInt(event.Rated),
 		event.DurationSeconds,
 	)
 	if err != nil {
@@ -104,6 +171,10 @@ func (s *AnalyticsStorage) SaveGameEvent(event *GameEvent) error {
 
 	event.ID, _ = result.LastInsertId()
 
+	if err := s.updateRatings(event); err != nil {
+		return err
+	}
+
 	// Update daily stats
 	return s.updateDailyStats(event)
 }
@@ -162,7 +233,7 @@ func (s *AnalyticsStorage) GetDailyStats(date string) (*DailyStats, error) {
 
 // GetRecentGames retrieves the most recent games
 func (s *AnalyticsStorage) GetRecentGames(limit int) ([]GameEvent, error) {
-	query := `SELECT id, room_code, player1_name, player2_name, winner, is_bot_game, duration_seconds, created_at 
+	query := `SELECT id, room_code, player1_name, player2_name, winner, reason, is_bot_game, duration_seconds, created_at 
 	          FROM game_events ORDER BY created_at DESC LIMIT ?`
 
 	rows, err := s.db.Query(query, limit)
@@ -175,7 +246,7 @@ func (s *AnalyticsStorage) GetRecentGames(limit int) ([]GameEvent, error) {
 	for rows.Next() {
 		var g GameEvent
 		var isBotGame int
-		if err := rows.Scan(&g.ID, &g.RoomCode, &g.Player1Name, &g.Player2Name, &g.Winner, &isBotGame, &g.DurationSeconds, &g.CreatedAt); err != nil {
+		if err := rows.Scan(&g.ID, &g.RoomCode, &g.Player1Name, &g.Player2Name, &g.Winner, &g.Reason, &isBotGame, &g.DurationSeconds, &g.CreatedAt); err != nil {
 			return nil, err
 		}
 		g.IsBotGame = isBotGame == 1
@@ -191,16 +262,32 @@ func (s *AnalyticsStorage) ProcessKafkaMessage(data []byte) error {
 		RoomCode        string `json:"room_code"`
 		Player1Name     string `json:"player1_name"`
 		Player2Name     string `json:"player2_name"`
+		Player1ID       string `json:"player1_id"`
+		Player2ID       string `json:"player2_id"`
 		Winner          int    `json:"winner"`
+		Reason          string `json:"reason"`
 		IsBotGame       bool   `json:"is_bot_game"`
+		Rated           bool   `json:"rated"`
 		DurationSeconds int64  `json:"duration_seconds"`
+
+		// account_upgraded
+		GuestID   string `json:"guest_id"`
+		AccountID string `json:"account_id"`
+		Username  string `json:"username"`
 	}
 
 	if err := json.Unmarshal(data, &msg); err != nil {
 		return err
 	}
 
-	if msg.Type != "game_completed" {
+	switch msg.Type {
+	case "game_completed":
+	case "account_upgraded":
+		if msg.GuestID == "" || msg.AccountID == "" {
+			return fmt.Errorf("account_upgraded event without guest_id or account_id")
+		}
+		return s.UpgradeGuest(msg.GuestID, msg.AccountID, msg.Username)
+	default:
 		log.Printf("Ignoring message of type: %s", msg.Type)
 		return nil
 	}
@@ -209,8 +296,12 @@ func (s *AnalyticsStorage) ProcessKafkaMessage(data []byte) error {
 		RoomCode:        msg.RoomCode,
 		Player1Name:     msg.Player1Name,
 		Player2Name:     msg.Player2Name,
+		Player1ID:       msg.Player1ID,
+		Player2ID:       msg.Player2ID,
 		Winner:          msg.Winner,
+		Reason:          msg.Reason,
 		IsBotGame:       msg.IsBotGame,
+		Rated:           msg.Rated,
 		DurationSeconds: msg.DurationSeconds,
 	}
 
@@ -219,42 +310,98 @@ func (s *AnalyticsStorage) ProcessKafkaMessage(data []byte) error {
 
 // LeaderboardEntry represents a player's ranking
 type LeaderboardEntry struct {
-	Name    string  `json:"name"`
-	Wins    int     `json:"wins"`
-	Games   int     `json:"games"`
-	WinRate float64 `json:"win_rate"`
+	PlayerID   string  `json:"player_id"` // empty for players only known from events without IDs
+	Name       string  `json:"name"`
+	Wins       int     `json:"wins"`
+	Games      int     `json:"games"`
+	WinRate    float64 `json:"win_rate"`
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	RatedGames int     `json:"rated_games"`
 }
 
-// GetLeaderboard returns top players ranked by wins
-func (s *AnalyticsStorage) GetLeaderboard(limit int) ([]LeaderboardEntry, error) {
+// Leaderboard orders
+const (
+	SortByWins   = "wins"
+	SortByRating = "rating"
+)
+
+// DefaultMinRatedGames keeps provisional ratings off the rating leaderboard
+const DefaultMinRatedGames = 5
+
+// LeaderboardOptions selects how the leaderboard is ranked
+type LeaderboardOptions struct {
+	Limit    int
+	SortBy   string // SortByWins or SortByRating
+	MinGames int    // games needed to be listed; rated games when sorting by rating
+}
+
+// GetLeaderboard returns the top players, by wins or by rating. Players are
+// told apart by account or guest ID, each listed under their latest name;
+// players of events from before IDs were recorded are told apart by name.
+func (s *AnalyticsStorage) GetLeaderboard(opts LeaderboardOptions) ([]LeaderboardEntry, error) {
 	// Aggregate wins from player perspective (player can be player1 or player2)
+	order, minGames := "total_wins DESC, total_games DESC", "total_games"
+	if opts.SortBy == SortByRating {
+		order, minGames = "rating DESC, rated_games DESC", "rated_games"
+	}
+
 	query := `
-	SELECT name, SUM(wins) as total_wins, SUM(games) as total_games
-	FROM (
+	WITH sides AS (
 		-- Games where player was player1
 		SELECT 
+			id as event_id,
+			player1_id as player_id,
 			player1_name as name,
-			CASE WHEN winner = 1 THEN 1 ELSE 0 END as wins,
-			1 as games
+			CASE WHEN winner = 1 THEN 1 ELSE 0 END as wins
 		FROM game_events 
-		WHERE player1_name != '' AND player1_name != 'bot'
+		WHERE player1_id != '' OR (player1_name != '' AND player1_name != 'bot')
 		
 		UNION ALL
 		
 		-- Games where player was player2 (only non-bot games)
 		SELECT 
+			id as event_id,
+			player2_id as player_id,
 			player2_name as name,
-			CASE WHEN winner = 2 THEN 1 ELSE 0 END as wins,
-			1 as games
+			CASE WHEN winner = 2 THEN 1 ELSE 0 END as wins
 		FROM game_events 
-		WHERE player2_name != '' AND player2_name != 'bot' AND is_bot_game = 0
+		WHERE is_bot_game = 0 AND (player2_id != '' OR (player2_name != '' AND player2_name != 'bot'))
+	),
+	keyed AS (
+		-- Events from before player IDs are told apart by name
+		SELECT *, CASE WHEN player_id != '' THEN player_id ELSE 'name:' || name END as player_key
+		FROM sides
+	),
+	totals AS (
+		SELECT 
+			player_key,
+			MAX(player_id) as player_id,
+			MAX(event_id) as latest_event,
+			SUM(wins) as total_wins,
+			COUNT(*) as total_games
+		FROM keyed
+		GROUP BY player_key
 	)
-	GROUP BY name
-	ORDER BY total_wins DESC, total_games DESC
+	SELECT player_id, name, total_wins, total_games, rating, deviation, rated_games
+	FROM (
+		SELECT 
+			t.player_id,
+			(SELECT k.name FROM keyed k WHERE k.player_key = t.player_key AND k.event_id = t.latest_event LIMIT 1) as name,
+			t.total_wins,
+			t.total_games,
+			COALESCE(r.rating, ?) as rating,
+			COALESCE(r.deviation, ?) as deviation,
+			COALESCE(r.games, 0) as rated_games
+		FROM totals t
+		LEFT JOIN player_ratings r ON t.player_id != '' AND r.player_id = t.player_id
+	)
+	WHERE ` + minGames + ` >= ?
+	ORDER BY ` + order + `
 	LIMIT ?
 	`
 
-	rows, err := s.db.Query(query, limit)
+	rows, err := s.db.Query(query, DefaultRating, DefaultDeviation, opts.MinGames, opts.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -263,7 +410,7 @@ func (s *AnalyticsStorage) GetLeaderboard(limit int) ([]LeaderboardEntry, error)
 	var leaderboard []LeaderboardEntry
 	for rows.Next() {
 		var entry LeaderboardEntry
-		if err := rows.Scan(&entry.Name, &entry.Wins, &entry.Games); err != nil {
+		if err := rows.Scan(&entry.PlayerID, &entry.Name, &entry.Wins, &entry.Games, &entry.Rating, &entry.Deviation, &entry.RatedGames); err != nil {
 			return nil, err
 		}
 		if entry.Games > 0 {
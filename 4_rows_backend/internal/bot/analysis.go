@@ -0,0 +1,167 @@
+package bot
+
+import (
+	"time"
+
+	"4_rows_backend/internal/game"
+	"4_rows_backend/internal/solver"
+)
+
+// Outcome is the predicted result of a move for the player making it
+type Outcome string
+
+const (
+	OutcomeWin     Outcome = "win"
+	OutcomeLoss    Outcome = "loss"
+	OutcomeDraw    Outcome = "draw"
+	OutcomeUnknown Outcome = "unknown" // only a heuristic value is available
+)
+
+// ColumnAnalysis describes what happens if the player to move plays a column
+type ColumnAnalysis struct {
+	Column    int
+	Playable  bool
+	Outcome   Outcome
+	Score     int // solver score when the analysis is exact, heuristic value otherwise
+	MovesLeft int // moves the winner still needs, for wins and losses
+}
+
+// Analysis is the engine's view of every move in a position
+type Analysis struct {
+	BestMove   int // column of the best move
+	BestAction game.MoveKind
+	Exact      bool
+	Columns    []ColumnAnalysis // dropping into each board column
+	Pops       []ColumnAnalysis // popping from each board column, nil if no pop is legal
+}
+
+// Analyze scores every move for player, who must be the player to move.
+// Classic positions covered by the opening book or solved within the budget get exact
+// results; otherwise each move gets a share of the budget for a heuristic search.
+func Analyze(board *game.Board, state *game.RuleState, rules game.Ruleset, player int, budget time.Duration) Analysis {
+	deadline := time.Now().Add(budget)
+	if rules.Variant() != game.VariantClassic || !board.IsClassic() {
+		return heuristicAnalysis(board, state, rules, player, deadline)
+	}
+	p := solver.FromBoard(board, player)
+
+	if scores, ok := bookScores(p); ok {
+		return exactAnalysis(board, player, scores)
+	}
+
+	solverMu.Lock()
+	if exactSolver == nil {
+		exactSolver = solver.New()
+	}
+	scores, err := exactSolver.AnalyzeWithin(p, false, time.Now().Add(budget/2))
+	solverMu.Unlock()
+	if err == nil {
+		return exactAnalysis(board, player, scores)
+	}
+
+	return heuristicAnalysis(board, state, rules, player, deadline)
+}
+
+func exactAnalysis(board *game.Board, player int, scores [solver.Width]int) Analysis {
+	// A solver score of s means the winner ends the game with this many discs played
+	discsAtEnd := func(s int) int {
+		if s < 0 {
+			s = -s
+		}
+		return solver.Width*solver.Height/2 + 1 - s
+	}
+	own := board.DiscCount(player)
+	opp := board.MoveCount() - own
+
+	analysis := Analysis{BestAction: game.MoveDrop, Exact: true, Columns: make([]ColumnAnalysis, len(scores))}
+	analysis.BestMove, _ = solver.BestMove(scores)
+
+	for col, score := range scores {
+		column := ColumnAnalysis{Column: col, Score: score}
+		switch {
+		case score == solver.InvalidScore:
+			column.Score = 0
+		case score > 0:
+			column.Playable = true
+			column.Outcome = OutcomeWin
+			column.MovesLeft = discsAtEnd(score) - own
+		case score < 0:
+			column.Playable = true
+			column.Outcome = OutcomeLoss
+			column.MovesLeft = discsAtEnd(score) - opp
+		default:
+			column.Playable = true
+			column.Outcome = OutcomeDraw
+		}
+		analysis.Columns[col] = column
+	}
+	return analysis
+}
+
+func heuristicAnalysis(board *game.Board, state *game.RuleState, rules game.Ruleset, player int, deadline time.Time) Analysis {
+	analysis := Analysis{BestMove: -1, Columns: make([]ColumnAnalysis, board.Cols())}
+	for col := range analysis.Columns {
+		analysis.Columns[col].Column = col
+	}
+
+	s := newSearcher(rules, 0)
+	pos := position{board: *board, state: state.Snapshot()}
+	moves := s.orderedMoves(&pos, player, 0, -1)
+	if len(moves) > 0 && moves[len(moves)-1].Kind == game.MovePop {
+		analysis.Pops = make([]ColumnAnalysis, board.Cols())
+		for col := range analysis.Pops {
+			analysis.Pops[col].Column = col
+		}
+	}
+
+	bestScore := 0
+	for i, move := range moves {
+		column := ColumnAnalysis{Column: move.Column, Playable: true}
+
+		child := pos
+		result, err := game.PlayMove(rules, &child.board, &child.state, move)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case result.GameOver && result.Winner == player:
+			column.Outcome = OutcomeWin
+			column.Score = winScore - 1
+			column.MovesLeft = 1
+		case result.GameOver && result.Winner == 0:
+			column.Outcome = OutcomeDraw
+		case result.GameOver:
+			column.Outcome = OutcomeLoss
+			column.Score = -winScore + 1
+		default:
+			share := time.Until(deadline) / time.Duration(len(moves)-i)
+			search := iterativeDeepening(child, rules, result.NextPlayer, maxPly, share)
+			column.Score = search.score
+			if result.NextPlayer != player {
+				column.Score = -search.score
+			}
+			column.Outcome = OutcomeUnknown
+
+			// Forced results found by the search are reported like exact ones
+			if plies := winScore + column.Score; plies <= maxPly {
+				column.Outcome = OutcomeLoss
+				column.MovesLeft = (plies + 1) / 2
+			} else if plies := winScore - column.Score; plies <= maxPly {
+				column.Outcome = OutcomeWin
+				column.MovesLeft = (plies + 2) / 2
+			}
+		}
+
+		if analysis.BestMove < 0 || column.Score > bestScore {
+			analysis.BestMove, analysis.BestAction, bestScore = move.Column, move.Kind, column.Score
+		}
+		if move.Kind == game.MovePop {
+			analysis.Pops[move.Column] = column
+		} else {
+			analysis.Columns[move.Column] = column
+		}
+	}
+
+	return analysis
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"4_rows_backend/internal/game"
+	"4_rows_backend/internal/solver"
+)
+
+// lateClassicPositions plays random classic games up to discs discs and
+// returns the unfinished positions with the player to move
+func lateClassicPositions(n, discs int, rng *rand.Rand) ([]game.Board, []int) {
+	var boards []game.Board
+	var players []int
+	for len(boards) < n {
+		board := game.NewBoard(game.ClassicDimensions)
+		over := false
+		for m := 0; m < discs && !over; m++ {
+			col := rng.Intn(board.Cols())
+			if !board.CanPlay(col) {
+				m--
+				continue
+			}
+			row, _ := board.Drop(col, m%2+1)
+			over, _ = board.CheckWin(row, col, m%2+1)
+		}
+		if !over {
+			boards = append(boards, board)
+			players = append(players, discs%2+1)
+		}
+	}
+	return boards, players
+}
+
+func TestHeuristicAnalysisMatchesSolver(t *testing.T) {
+	rules := game.RulesFor(game.VariantClassic)
+	boards, players := lateClassicPositions(6, 22, rand.New(rand.NewSource(1)))
+	for i := range boards {
+		board, player := &boards[i], players[i]
+		exact := exactAnalysis(board, player, solver.New().Analyze(solver.FromBoard(board, player), false))
+
+		sharedTable = newTranspositionTable(defaultTableEntries)
+		state := game.NewRuleState()
+		heuristic := heuristicAnalysis(board, &state, rules, player, time.Now().Add(time.Minute))
+
+		for col, want := range exact.Columns {
+			got := heuristic.Columns[col]
+			if got.Playable != want.Playable {
+				t.Fatalf("position %d column %d: playable %v, want %v", i, col+1, got.Playable, want.Playable)
+			}
+			if !want.Playable {
+				continue
+			}
+			outcome := got.Outcome
+			if outcome == OutcomeUnknown && got.Score == 0 {
+				outcome = OutcomeDraw
+			}
+			// A forced result the search proves can take longer than the
+			// fastest one, as table entries from other columns cut it short
+			if outcome != want.Outcome || got.MovesLeft < want.MovesLeft {
+				t.Errorf("position %d column %d: %s in %d, solver says %s in %d",
+					i, col+1, outcome, got.MovesLeft, want.Outcome, want.MovesLeft)
+			}
+		}
+		if got, want := heuristic.Columns[heuristic.BestMove].Outcome, exact.Columns[exact.BestMove].Outcome; got != want && !(got == OutcomeUnknown && want == OutcomeDraw) {
+			t.Errorf("position %d: best move %d leads to %s, solver's best %d to %s",
+				i, heuristic.BestMove+1, got, exact.BestMove+1, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"4_rows_backend/internal/game"
+	"4_rows_backend/internal/solver"
+)
+
+var (
+	openingBook *solver.Book
+
+	// exactSolver is created on first use because its table is large; searches take turns on it
+	exactSolver *solver.Solver
+	solverMu    sync.Mutex
+)
+
+// LoadOpeningBook loads the precomputed book used by the hard and perfect levels
+func LoadOpeningBook(path string) error {
+	book, err := solver.LoadBook(path)
+	if err != nil {
+		return err
+	}
+
+	openingBook = book
+	log.Printf("Opening book loaded: %d positions up to %d discs", book.Len(), book.Depth())
+	return nil
+}
+
+// bookMove picks the best column from the opening book, if the position is covered
+func bookMove(board *game.Board, player int) (int, bool) {
+	if !board.IsClassic() {
+		return -1, false
+	}
+	scores, ok := bookScores(solver.FromBoard(board, player))
+	if !ok {
+		return -1, false
+	}
+
+	col, _ := solver.BestMove(scores)
+	return col, col >= 0
+}
+
+// bookScores looks up every child position in the opening book
+func bookScores(p solver.Position) ([solver.Width]int, bool) {
+	var scores [solver.Width]int
+	book := openingBook
+	if book == nil || p.Moves() >= book.Depth() {
+		return scores, false
+	}
+
+	for col := range scores {
+		switch {
+		case !p.CanPlay(col):
+			scores[col] = solver.InvalidScore
+		case p.IsWinningMove(col):
+			scores[col] = (solver.Width*solver.Height + 1 - p.Moves()) / 2
+		default:
+			child := p
+			child.Play(col)
+			score, ok := book.Score(child)
+			if !ok {
+				return scores, false
+			}
+			scores[col] = -score
+		}
+	}
+	return scores, true
+}
+
+// solvedMove searches for the game-theoretic best column, giving up at the deadline.
+// Only classic boards can be solved.
+func solvedMove(board *game.Board, player int, deadline time.Time) (int, bool) {
+	if !board.IsClassic() {
+		return -1, false
+	}
+
+	solverMu.Lock()
+	defer solverMu.Unlock()
+
+	if exactSolver == nil {
+		exactSolver = solver.New()
+	}
+
+	scores, err := exactSolver.AnalyzeWithin(solver.FromBoard(board, player), false, deadline)
+	if err != nil {
+		return -1, false
+	}
+
+	col, _ := solver.BestMove(scores)
+	return col, col >= 0
+}
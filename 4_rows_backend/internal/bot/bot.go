@@ -8,110 +8,133 @@ import (
 )
 
 const (
-	// Bot is always player 2
+	// Bot always sits as player 2, though it may move first
 	BotPlayerNumber = 2
 )
 
 type Bot struct {
 	playerNumber int
+	difficulty   Difficulty
+	timeLimit    time.Duration // caps the difficulty's time budget, zero for no cap
 }
 
-// NewBot creates a new bot instance
+// NewBot creates a new bot instance at the default difficulty
 func NewBot() *Bot {
+	return NewBotWithDifficulty(DefaultDifficulty)
+}
+
+// NewBotWithDifficulty creates a bot that searches as deep as the difficulty allows
+func NewBotWithDifficulty(difficulty Difficulty) *Bot {
 	rand.Seed(time.Now().UnixNano())
 	return &Bot{
 		playerNumber: BotPlayerNumber,
+		difficulty:   difficulty,
 	}
 }
 
-// GetBestMove returns the best column to play in
-// Uses a simple heuristic: 1) Win, 2) Block, 3) Center preference
+// Difficulty returns the bot's difficulty level
+func (b *Bot) Difficulty() Difficulty {
+	return b.difficulty
+}
+
+// SetTimeLimit caps how long the bot thinks about a move, such as a share of
+// its remaining clock in a timed game. Zero leaves the difficulty's budget.
+func (b *Bot) SetTimeLimit(limit time.Duration) {
+	b.timeLimit = limit
+}
+
+// GetBestMove returns the best column to play in under classic rules
 func (b *Bot) GetBestMove(board *game.Board, humanPlayer int) int {
+	var state game.RuleState
+	return b.ChooseMove(board, &state, game.RulesFor(game.VariantClassic), humanPlayer).Column
+}
+
+// ChooseMove returns the best move under rules, or a move with column -1 if the
+// bot has no legal move. Immediate wins and blocks are always taken; otherwise
+// the move comes from the opening book, the exact solver or an iterative
+// deepening alpha-beta search, depending on the difficulty settings.
+func (b *Bot) ChooseMove(board *game.Board, state *game.RuleState, rules game.Ruleset, humanPlayer int) game.Move {
 	cpuPlayer := b.playerNumber
-	validMoves := b.getValidMoves(board)
+	validMoves := rules.LegalMoves(board, state, cpuPlayer, nil)
 
 	if len(validMoves) == 0 {
-		return -1
+		return game.Move{Column: -1, PlayerNum: cpuPlayer}
 	}
 
 	// Priority 1: Check for winning move
-	for _, col := range validMoves {
-		if b.wouldWin(board, col, cpuPlayer) {
-			return col
+	for _, move := range validMoves {
+		if wouldWin(board, state, rules, move) {
+			return move
 		}
 	}
 
-	// Priority 2: Block opponent's winning move
-	for _, col := range validMoves {
-		if b.wouldWin(board, col, humanPlayer) {
-			return col
-		}
+	settings := b.difficulty.settings()
+	if b.timeLimit > 0 {
+		settings.timeBudget = min(settings.timeBudget, b.timeLimit)
 	}
 
-	// Priority 3: Center preference (columns ranked by strategic value)
-	preference := []int{3, 2, 4, 1, 5, 0, 6}
-	for _, col := range preference {
-		if b.isValidMove(validMoves, col) {
-			return col
-		}
+	// Weaker levels occasionally play a random move
+	if settings.blunderRate > 0 && rand.Float64() < settings.blunderRate {
+		return validMoves[rand.Intn(len(validMoves))]
 	}
 
-	// Fallback: random valid move
-	return validMoves[rand.Intn(len(validMoves))]
-}
-
-// getValidMoves returns all columns that are not full
-func (b *Bot) getValidMoves(board *game.Board) []int {
-	var validMoves []int
-	for col := 0; col < game.Cols; col++ {
-		if board.Grid[0][col] == 0 {
-			validMoves = append(validMoves, col)
+	// Priority 2: Block opponent's winning drop by dropping there first
+	for _, threat := range rules.LegalMoves(board, state, humanPlayer, nil) {
+		if threat.Kind != game.MoveDrop || !wouldWin(board, state, rules, threat) {
+			continue
+		}
+		for _, move := range validMoves {
+			if move.Kind == game.MoveDrop && move.Column == threat.Column {
+				return move
+			}
 		}
 	}
-	return validMoves
-}
 
-// isValidMove checks if a column is in the valid moves list
-func (b *Bot) isValidMove(validMoves []int, col int) bool {
-	for _, v := range validMoves {
-		if v == col {
-			return true
+	// The book and the solver only know classic rules
+	if rules.Variant() == game.VariantClassic {
+		// Priority 3: Opening book
+		if settings.useBook {
+			if col, ok := bookMove(board, cpuPlayer); ok {
+				return b.drop(col)
+			}
 		}
-	}
-	return false
-}
 
-// wouldWin simulates a move and checks if it would result in a win
-func (b *Bot) wouldWin(board *game.Board, col int, player int) bool {
-	// Find the row where the disc would land
-	row := -1
-	for r := game.Rows - 1; r >= 0; r-- {
-		if board.Grid[r][col] == 0 {
-			row = r
-			break
+		// Priority 4: Perfect play, if the solver finishes within most of the budget
+		if settings.exact {
+			started := time.Now()
+			if col, ok := solvedMove(board, cpuPlayer, started.Add(settings.timeBudget*2/3)); ok {
+				return b.drop(col)
+			}
+			settings.timeBudget -= time.Since(started)
 		}
 	}
 
-	if row < 0 {
-		return false
+	// Priority 5: Search
+	pos := position{board: *board, state: state.Snapshot()}
+	result := iterativeDeepening(pos, rules, cpuPlayer, settings.maxDepth, settings.timeBudget)
+	if result.found {
+		return result.move
+	}
+
+	// Fallback: center preference if the search ran out of time before finishing a single ply
+	for _, col := range columnOrder(board) {
+		for _, move := range validMoves {
+			if move.Column == col {
+				return move
+			}
+		}
 	}
 
-	// Simulate the move on a copy of the board
-	tempBoard := b.copyBoard(board)
-	tempBoard.Grid[row][col] = player
+	return validMoves[rand.Intn(len(validMoves))]
+}
 
-	// Check if this results in a win
-	won, _ := tempBoard.CheckWin(row, col, player)
-	return won
+func (b *Bot) drop(col int) game.Move {
+	return game.Move{Kind: game.MoveDrop, Column: col, PlayerNum: b.playerNumber}
 }
 
-// copyBoard creates a deep copy of the board
-func (b *Bot) copyBoard(board *game.Board) *game.Board {
-	newBoard := &game.Board{}
-	for r := 0; r < game.Rows; r++ {
-		for c := 0; c < game.Cols; c++ {
-			newBoard.Grid[r][c] = board.Grid[r][c]
-		}
-	}
-	return newBoard
+// wouldWin checks if the move would end the game in favour of the player making it
+func wouldWin(board *game.Board, state *game.RuleState, rules game.Ruleset, move game.Move) bool {
+	child, childState := *board, state.Snapshot()
+	result, err := game.PlayMove(rules, &child, &childState, move)
+	return err == nil && result.GameOver && result.Winner == move.PlayerNum
 }
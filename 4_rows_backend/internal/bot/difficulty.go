@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+)
+
+// Difficulty names a bot strength level that can be requested by clients
+type Difficulty string
+
+const (
+	DifficultyEasy    Difficulty = "easy"
+	DifficultyMedium  Difficulty = "medium"
+	DifficultyHard    Difficulty = "hard"
+	DifficultyPerfect Difficulty = "perfect"
+
+	DefaultDifficulty = DifficultyMedium
+)
+
+// searchSettings controls how hard the engine thinks at a given difficulty
+type searchSettings struct {
+	maxDepth    int
+	timeBudget  time.Duration
+	blunderRate float64 // chance of playing a random move instead of searching
+	useBook     bool    // play from the opening book while the position is in it
+	exact       bool    // try the perfect-play solver before the heuristic search
+}
+
+var difficultySettings = map[Difficulty]searchSettings{
+	DifficultyEasy:    {maxDepth: 2, timeBudget: 100 * time.Millisecond, blunderRate: 0.25},
+	DifficultyMedium:  {maxDepth: 5, timeBudget: 300 * time.Millisecond},
+	DifficultyHard:    {maxDepth: 42, timeBudget: 3 * time.Second, useBook: true, exact: true},
+	DifficultyPerfect: {maxDepth: 42, timeBudget: 10 * time.Second, useBook: true, exact: true},
+}
+
+// ParseDifficulty converts a client supplied name into a Difficulty.
+// An empty name selects DefaultDifficulty.
+func ParseDifficulty(name string) (Difficulty, error) {
+	if name == "" {
+		return DefaultDifficulty, nil
+	}
+
+	d := Difficulty(name)
+	if _, ok := difficultySettings[d]; !ok {
+		return "", fmt.Errorf("unknown difficulty %q", name)
+	}
+	return d, nil
+}
+
+func (d Difficulty) settings() searchSettings {
+	if s, ok := difficultySettings[d]; ok {
+		return s
+	}
+	return difficultySettings[DefaultDifficulty]
+}
+
+// DifficultyForRating picks the bot level closest in strength to a player with the given rating
+func DifficultyForRating(rating float64) Difficulty {
+	switch {
+	case rating < 1300:
+		return DifficultyEasy
+	case rating < 1650:
+		return DifficultyMedium
+	case rating < 2000:
+		return DifficultyHard
+	}
+	return DifficultyPerfect
+}
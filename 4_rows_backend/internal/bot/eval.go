@@ -0,0 +1,106 @@
+package bot
+
+import "4_rows_backend/internal/game"
+
+const (
+	winScore = 1000000
+
+	threeScore        = 50
+	twoScore          = 10
+	centerScore       = 6
+	parityThreatBonus = 40
+	keptScore         = 200 // per disc kept in Pop Ten
+)
+
+// evaluate scores a non-terminal position from player's point of view.
+// It rewards lines one or two discs short of a win, discs in the center column,
+// and threats on the row parity that favours the player (odd rows for
+// the first player, even rows for the second, counting from the bottom).
+func evaluate(board *game.Board, player int) int {
+	opponent := 3 - player
+	score := 0
+
+	rows, cols, connect := board.Rows(), board.Cols(), board.Connect()
+	var threats [game.MaxRows][game.MaxCols]int
+
+	// Every cell is part of many windows, so read the board once
+	var grid [game.MaxRows][game.MaxCols]int
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			grid[r][c] = board.Cell(r, c)
+		}
+	}
+	dirs := [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			for _, d := range dirs {
+				endR, endC := r+d[0]*(connect-1), c+d[1]*(connect-1)
+				if endR < 0 || endR >= rows || endC < 0 || endC >= cols {
+					continue
+				}
+
+				own, opp := 0, 0
+				emptyR, emptyC := -1, -1
+				for i := 0; i < connect; i++ {
+					switch grid[r+d[0]*i][c+d[1]*i] {
+					case player:
+						own++
+					case opponent:
+						opp++
+					default:
+						emptyR, emptyC = r+d[0]*i, c+d[1]*i
+					}
+				}
+
+				switch {
+				case opp == 0 && own == connect-1:
+					score += threeScore
+					threats[emptyR][emptyC] |= player
+				case opp == 0 && own == connect-2:
+					score += twoScore
+				case own == 0 && opp == connect-1:
+					score -= threeScore
+					threats[emptyR][emptyC] |= opponent
+				case own == 0 && opp == connect-2:
+					score -= twoScore
+				}
+			}
+		}
+	}
+
+	center := cols / 2
+	for r := 0; r < rows; r++ {
+		switch grid[r][center] {
+		case player:
+			score += centerScore
+		case opponent:
+			score -= centerScore
+		}
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			owners := threats[r][c]
+			if owners == 0 {
+				continue
+			}
+			// Player 1 moves first and profits from odd-row threats, player 2 from even ones
+			oddRow := (rows-r)%2 == 1
+			favoured := 2
+			if oddRow {
+				favoured = 1
+			}
+			if owners&favoured == 0 {
+				continue
+			}
+			if favoured == player {
+				score += parityThreatBonus
+			} else {
+				score -= parityThreatBonus
+			}
+		}
+	}
+
+	return score
+}
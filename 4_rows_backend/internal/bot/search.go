@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+// nodesPerTimeCheck controls how often the search looks at the clock
+const nodesPerTimeCheck = 1024
+
+// position is a board together with the rule state the search needs
+type position struct {
+	board game.Board
+	state game.RuleState
+}
+
+// searcher runs a negamax search with alpha-beta pruning under a game.Ruleset,
+// copying the position for every move it tries
+type searcher struct {
+	rules      game.Ruleset
+	misere     bool   // the evaluation is turned around when completing a line loses
+	variantKey uint64 // keeps scores of different variants apart in the shared table
+	table      *transpositionTable
+	deadline   time.Time
+	nodes      int
+	aborted    bool
+	moves      [maxPly + 1][2 * game.MaxCols]game.Move // move list buffer per ply
+}
+
+// searchResult is the outcome of the deepest fully completed iteration
+type searchResult struct {
+	move  game.Move
+	found bool
+	score int
+	depth int
+}
+
+// Moves are stored in the transposition table as ints: the column for a drop,
+// popMove plus the column for a pop
+const popMove = game.MaxCols
+
+func encodeMove(m game.Move) int {
+	if m.Kind == game.MovePop {
+		return popMove + m.Column
+	}
+	return m.Column
+}
+
+// columnOrders lists, for every board width, the columns from the center
+// outwards, which are usually the strongest
+var columnOrders = func() [game.MaxCols + 1][]int {
+	var orders [game.MaxCols + 1][]int
+	for cols := game.MinCols; cols <= game.MaxCols; cols++ {
+		order := make([]int, 0, cols)
+		center := cols / 2
+		order = append(order, center)
+		for offset := 1; offset <= center; offset++ {
+			if center-offset >= 0 {
+				order = append(order, center-offset)
+			}
+			if center+offset < cols {
+				order = append(order, center+offset)
+			}
+		}
+		orders[cols] = order
+	}
+	return orders
+}()
+
+// columnOrder returns the center-first column order for a board
+func columnOrder(board *game.Board) []int {
+	return columnOrders[board.Cols()]
+}
+
+// columnRanks gives, for every board width, the position of each column in its columnOrder
+var columnRanks = func() [game.MaxCols + 1][game.MaxCols]int {
+	var ranks [game.MaxCols + 1][game.MaxCols]int
+	for cols, order := range columnOrders {
+		for rank, col := range order {
+			ranks[cols][col] = rank
+		}
+	}
+	return ranks
+}()
+
+func newSearcher(rules game.Ruleset, budget time.Duration) *searcher {
+	return &searcher{
+		rules:      rules,
+		misere:     rules.Variant() == game.VariantAntiConnect,
+		variantKey: variantKey(rules.Variant()),
+		table:      sharedTable,
+		deadline:   time.Now().Add(budget),
+	}
+}
+
+// iterativeDeepening searches one ply deeper per iteration until maxDepth
+// is reached or the time budget runs out.
+func iterativeDeepening(pos position, rules game.Ruleset, player int, maxDepth int, budget time.Duration) searchResult {
+	s := newSearcher(rules, budget)
+
+	var best searchResult
+	for depth := 1; depth <= maxDepth && depth <= maxPly; depth++ {
+		hashMove := -1
+		if best.found {
+			hashMove = encodeMove(best.move)
+		}
+		move, score, ok := s.searchRoot(&pos, player, depth, hashMove)
+		if s.aborted || !ok {
+			break
+		}
+
+		best = searchResult{move: move, found: true, score: score, depth: depth}
+
+		// A forced result will not change with more depth
+		if score >= winScore-maxPly || score <= -winScore+maxPly {
+			break
+		}
+	}
+
+	return best
+}
+
+// sideToMoveKeys are mixed into the board hash so the same discs with a different player to move do not collide
+var sideToMoveKeys = [3]uint64{0, 0x2d358dccaa6c78a5, 0x8bb84b93962eacc9}
+
+// variantKey is mixed into the hash of every position searched under the variant
+func variantKey(v game.Variant) uint64 {
+	if v == game.VariantClassic {
+		return 0
+	}
+	// FNV-1a of the name is enough to tell a handful of variants apart
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(v); i++ {
+		h ^= uint64(v[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// maxPly bounds the distance to a forced result that the scores encode
+const maxPly = game.MaxRows * game.MaxCols
+
+func (s *searcher) key(pos *position, player int) uint64 {
+	return pos.board.Hash() ^ sideToMoveKeys[player] ^ s.variantKey ^ pos.state.Hash()
+}
+
+func (s *searcher) searchRoot(pos *position, player int, depth int, firstMove int) (game.Move, int, bool) {
+	alpha, beta := -winScore-1, winScore+1
+	var bestMove game.Move
+	found := false
+
+	for _, move := range s.orderedMoves(pos, player, 0, firstMove) {
+		child := *pos
+		result, err := game.PlayMove(s.rules, &child.board, &child.state, move)
+		if err != nil {
+			continue
+		}
+
+		score := s.scoreResult(&child, result, depth, alpha, beta, 1)
+		if s.aborted {
+			return bestMove, alpha, found
+		}
+		if !found || score > alpha {
+			alpha = score
+			bestMove, found = result.Move, true
+		}
+	}
+
+	return bestMove, alpha, found
+}
+
+// negamax returns the score of the position for player, who is about to move
+func (s *searcher) negamax(pos *position, player int, depth int, alpha int, beta int, ply int) int {
+	s.nodes++
+	if s.nodes%nodesPerTimeCheck == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+		return 0
+	}
+
+	if depth == 0 || ply >= maxPly {
+		return s.evaluate(pos, player)
+	}
+
+	key := s.key(pos, player)
+	hashMove := -1
+	if entry, ok := s.table.probe(key); ok {
+		hashMove = int(entry.bestMove)
+		if int(entry.depth) >= depth {
+			score := scoreFromTable(int(entry.score), ply)
+			switch entry.bound {
+			case boundExact:
+				return score
+			case boundLower:
+				alpha = max(alpha, score)
+			case boundUpper:
+				beta = min(beta, score)
+			}
+			if alpha >= beta {
+				return score
+			}
+		}
+	}
+
+	alphaOrig := alpha
+	best, bestMove := -winScore-1, -1
+	for _, move := range s.orderedMoves(pos, player, ply, hashMove) {
+		child := *pos
+		result, err := game.PlayMove(s.rules, &child.board, &child.state, move)
+		if err != nil {
+			continue
+		}
+
+		score := s.scoreResult(&child, result, depth, alpha, beta, ply+1)
+		if s.aborted {
+			return 0
+		}
+		if score > best {
+			best, bestMove = score, encodeMove(move)
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	if bestMove < 0 {
+		return 0 // no legal move left, the game is drawn
+	}
+
+	bound := boundExact
+	if best <= alphaOrig {
+		bound = boundUpper
+	} else if best >= beta {
+		bound = boundLower
+	}
+	s.table.store(key, depth, scoreToTable(best, ply), bound, bestMove)
+
+	return best
+}
+
+// scoreResult scores a move from the point of view of the player who made it
+func (s *searcher) scoreResult(child *position, result game.MoveResult, depth, alpha, beta, ply int) int {
+	mover := result.Move.PlayerNum
+	if result.GameOver {
+		switch result.Winner {
+		case mover:
+			return winScore - ply
+		case 0:
+			return 0
+		default:
+			return -winScore + ply
+		}
+	}
+
+	// The opponent had no legal move and passed
+	if result.NextPlayer == mover {
+		return s.negamax(child, mover, depth-1, alpha, beta, ply)
+	}
+	return -s.negamax(child, result.NextPlayer, depth-1, -beta, -alpha, ply)
+}
+
+// orderedMoves lists the legal moves with the hash move first, then drops and
+// pops from the center outwards
+func (s *searcher) orderedMoves(pos *position, player int, ply int, first int) []game.Move {
+	moves := s.rules.LegalMoves(&pos.board, &pos.state, player, s.moves[ply][:0])
+	ranks := &columnRanks[pos.board.Cols()]
+
+	rank := func(m game.Move) int {
+		code := encodeMove(m)
+		switch {
+		case code == first:
+			return -1
+		case m.Kind == game.MovePop:
+			return game.MaxCols + ranks[m.Column]
+		}
+		return ranks[m.Column]
+	}
+
+	// Insertion sort, the lists are short
+	for i := 1; i < len(moves); i++ {
+		for j := i; j > 0 && rank(moves[j]) < rank(moves[j-1]); j-- {
+			moves[j], moves[j-1] = moves[j-1], moves[j]
+		}
+	}
+	return moves
+}
+
+// evaluate scores a non-terminal position for player
+func (s *searcher) evaluate(pos *position, player int) int {
+	score := evaluate(&pos.board, player)
+	if s.misere {
+		score = -score
+	}
+	return score + keptScore*(pos.state.Kept[player-1]-pos.state.Kept[2-player])
+}
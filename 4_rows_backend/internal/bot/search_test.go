@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+// minimax scores a position for player like searcher.negamax, without pruning or the table
+func minimax(s *searcher, pos position, player, depth, ply int) int {
+	if depth == 0 || ply >= maxPly {
+		return s.evaluate(&pos, player)
+	}
+
+	best, found := 0, false
+	for _, move := range s.rules.LegalMoves(&pos.board, &pos.state, player, nil) {
+		child := pos
+		child.state = pos.state.Snapshot()
+		result, err := game.PlayMove(s.rules, &child.board, &child.state, move)
+		if err != nil {
+			continue
+		}
+
+		var score int
+		switch {
+		case result.GameOver && result.Winner == player:
+			score = winScore - ply - 1
+		case result.GameOver && result.Winner == 0:
+			score = 0
+		case result.GameOver:
+			score = -winScore + ply + 1
+		case result.NextPlayer == player:
+			score = minimax(s, child, player, depth-1, ply+1)
+		default:
+			score = -minimax(s, child, result.NextPlayer, depth-1, ply+1)
+		}
+		if !found || score > best {
+			best, found = score, true
+		}
+	}
+	return best
+}
+
+// randomPositions plays random games under rules and returns unfinished
+// positions with the player to move
+func randomPositions(rules game.Ruleset, n int, rng *rand.Rand) ([]position, []int) {
+	d, _ := rules.Dimensions(game.Dimensions{})
+	var positions []position
+	var players []int
+	for len(positions) < n {
+		pos := position{board: rules.NewBoard(d), state: game.NewRuleState()}
+		player := 1
+		over := false
+		for plies := 2 + rng.Intn(20); plies > 0 && !over; plies-- {
+			moves := rules.LegalMoves(&pos.board, &pos.state, player, nil)
+			result, err := game.PlayMove(rules, &pos.board, &pos.state, moves[rng.Intn(len(moves))])
+			if err != nil {
+				panic(err)
+			}
+			over, player = result.GameOver, result.NextPlayer
+		}
+		if !over {
+			positions = append(positions, pos)
+			players = append(players, player)
+		}
+	}
+	return positions, players
+}
+
+func TestIterativeDeepeningMatchesMinimax(t *testing.T) {
+	const depth = 4
+	for _, variant := range []game.Variant{game.VariantClassic, game.VariantAntiConnect} {
+		t.Run(string(variant), func(t *testing.T) {
+			rules := game.RulesFor(variant)
+			positions, players := randomPositions(rules, 100, rand.New(rand.NewSource(1)))
+			for i, pos := range positions {
+				// Scores left in the table by deeper searches would differ from a depth limited minimax
+				sharedTable = newTranspositionTable(defaultTableEntries)
+
+				want := minimax(newSearcher(rules, 0), pos, players[i], depth, 0)
+				got := iterativeDeepening(pos, rules, players[i], depth, time.Minute)
+				if !got.found || got.score != want {
+					t.Errorf("position %d: search score %d, minimax %d", i, got.score, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package bot
+
+import "sync"
+
+const (
+	// defaultTableEntries bounds the shared table to a few megabytes
+	defaultTableEntries = 1 << 18
+	tableShards         = 64
+)
+
+// boundType records how a stored score relates to the true value of the position
+type boundType uint8
+
+const (
+	boundExact boundType = iota
+	boundLower           // search failed high, true score is at least the stored one
+	boundUpper           // search failed low, true score is at most the stored one
+)
+
+type ttEntry struct {
+	key      uint64
+	score    int32
+	depth    int8
+	bound    boundType
+	bestMove int8
+}
+
+// transpositionTable is a fixed-size cache of search results keyed by Zobrist hash.
+// It is split into independently locked shards so concurrent bot games can share it.
+type transpositionTable struct {
+	shards [tableShards]ttShard
+}
+
+type ttShard struct {
+	mu      sync.Mutex
+	entries []ttEntry
+}
+
+// sharedTable is used by every bot search in the process
+var sharedTable = newTranspositionTable(defaultTableEntries)
+
+func newTranspositionTable(entries int) *transpositionTable {
+	perShard := entries / tableShards
+	if perShard < 1 {
+		perShard = 1
+	}
+
+	t := &transpositionTable{}
+	for i := range t.shards {
+		t.shards[i].entries = make([]ttEntry, perShard)
+	}
+	return t
+}
+
+func (t *transpositionTable) slot(key uint64) (*ttShard, int) {
+	shard := &t.shards[key%tableShards]
+	return shard, int((key / tableShards) % uint64(len(shard.entries)))
+}
+
+// probe returns the entry stored for key, if any
+func (t *transpositionTable) probe(key uint64) (ttEntry, bool) {
+	shard, i := t.slot(key)
+	shard.mu.Lock()
+	entry := shard.entries[i]
+	shard.mu.Unlock()
+
+	return entry, entry.key == key && key != 0
+}
+
+// store saves a search result, keeping the deeper entry when the same position is already present
+func (t *transpositionTable) store(key uint64, depth int, score int, bound boundType, bestMove int) {
+	shard, i := t.slot(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	current := &shard.entries[i]
+	if current.key == key && int(current.depth) > depth {
+		return
+	}
+
+	*current = ttEntry{
+		key:      key,
+		score:    int32(score),
+		depth:    int8(depth),
+		bound:    bound,
+		bestMove: int8(bestMove),
+	}
+}
+
+// Win scores encode the ply of the win from the root. The table stores them
+// relative to the node instead, so they stay valid when reached at another ply.
+func scoreToTable(score int, ply int) int {
+	switch {
+	case score >= winScore-maxPly:
+		return score + ply
+	case score <= -winScore+maxPly:
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTable(score int, ply int) int {
+	switch {
+	case score >= winScore-maxPly:
+		return score - ply
+	case score <= -winScore+maxPly:
+		return score + ply
+	}
+	return score
+}
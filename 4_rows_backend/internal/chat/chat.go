@@ -0,0 +1,246 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength is the longest chat message in characters
+	MaxLength = 200
+	// HistorySize is how many recent messages a room keeps for state snapshots
+	HistorySize = 30
+
+	DefaultRateLimit  = 5
+	DefaultRateWindow = 10 * time.Second
+)
+
+var (
+	ErrEmptyMessage   = errors.New("message is empty")
+	ErrMessageTooLong = errors.New("message is too long")
+	ErrUnknownEmote   = errors.New("unknown emote")
+	ErrRateLimited    = errors.New("sending messages too quickly")
+)
+
+// Kind tells a typed message from a quick emote
+type Kind string
+
+const (
+	KindText  Kind = "chat"
+	KindEmote Kind = "emote"
+)
+
+// Channel is who a message is delivered to
+type Channel string
+
+const (
+	ChannelRoom       Channel = "room"       // everyone in the room
+	ChannelSpectators Channel = "spectators" // spectators only
+)
+
+// Emotes are the quick reactions a client may send
+var Emotes = []string{"hello", "good_luck", "nice_move", "oops", "thinking", "wow", "thanks", "good_game"}
+
+// Message is a chat message or emote sent in a room
+type Message struct {
+	Kind         Kind
+	Channel      Channel
+	Sender       string
+	PlayerNumber int // 0 for spectators
+	Text         string
+	SentAt       time.Time
+}
+
+// Config controls filtering and rate limiting of chat
+type Config struct {
+	BannedWords      []string // masked with asterisks, matched as whole words ignoring case
+	RateLimit        int      // messages a client may send per RateWindow
+	RateWindow       time.Duration
+	SpectatorChannel bool // spectators chat among themselves instead of with the players
+}
+
+// Manager filters messages and keeps the recent history of every room
+type Manager struct {
+	config  Config
+	banned  map[string]bool
+	history map[string][]Message
+	mu      sync.Mutex
+}
+
+var manager *Manager
+
+// NewManager creates the global chat manager, filling in defaults for unset limits
+func NewManager(config Config) *Manager {
+	if config.RateLimit <= 0 {
+		config.RateLimit = DefaultRateLimit
+	}
+	if config.RateWindow <= 0 {
+		config.RateWindow = DefaultRateWindow
+	}
+
+	banned := make(map[string]bool)
+	for _, word := range config.BannedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			banned[word] = true
+		}
+	}
+
+	manager = &Manager{
+		config:  config,
+		banned:  banned,
+		history: make(map[string][]Message),
+	}
+	return manager
+}
+
+// GetManager returns the global chat manager, creating one with default settings if needed
+func GetManager() *Manager {
+	if manager == nil {
+		NewManager(Config{})
+	}
+	return manager
+}
+
+// SpectatorChannel reports whether spectators are kept in a channel of their own
+func (m *Manager) SpectatorChannel() bool {
+	return m.config.SpectatorChannel
+}
+
+// RateLimit returns how many messages a client may send per RateWindow
+func (m *Manager) RateLimit() int {
+	return m.config.RateLimit
+}
+
+// RateWindow returns the window messages are counted in
+func (m *Manager) RateWindow() time.Duration {
+	return m.config.RateWindow
+}
+
+// NewLimiter returns a rate limiter for one client
+func (m *Manager) NewLimiter() *Limiter {
+	return &Limiter{limit: m.config.RateLimit, window: m.config.RateWindow}
+}
+
+// Compose checks the text of a message of the given kind and returns it as it should be sent
+func (m *Manager) Compose(kind Kind, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if kind == KindEmote {
+		for _, emote := range Emotes {
+			if text == emote {
+				return text, nil
+			}
+		}
+		return "", fmt.Errorf("%w %q", ErrUnknownEmote, text)
+	}
+
+	if text == "" {
+		return "", ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > MaxLength {
+		return "", fmt.Errorf("%w: at most %d characters", ErrMessageTooLong, MaxLength)
+	}
+	return m.Filter(text), nil
+}
+
+// Filter masks the banned words in text
+func (m *Manager) Filter(text string) string {
+	if len(m.banned) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	word := -1 // byte offset where the current word started
+	flush := func(end int) {
+		if word < 0 {
+			return
+		}
+		if w := text[word:end]; m.banned[strings.ToLower(w)] {
+			sb.WriteString(strings.Repeat("*", utf8.RuneCountInString(w)))
+		} else {
+			sb.WriteString(w)
+		}
+		word = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if word < 0 {
+				word = i
+			}
+			continue
+		}
+		flush(i)
+		sb.WriteRune(r)
+	}
+	flush(len(text))
+	return sb.String()
+}
+
+// Record adds a message to a room's history, dropping the oldest beyond HistorySize
+func (m *Manager) Record(roomCode string, msg Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := append(m.history[roomCode], msg)
+	if len(history) > HistorySize {
+		history = history[len(history)-HistorySize:]
+	}
+	m.history[roomCode] = history
+}
+
+// History returns the recent messages of a room a client may see. Players do
+// not see the spectator channel.
+func (m *Manager) History(roomCode string, spectator bool) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var messages []Message
+	for _, msg := range m.history[roomCode] {
+		if msg.Channel == ChannelSpectators && !spectator {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// Clear forgets the history of a room that has been removed
+func (m *Manager) Clear(roomCode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.history, roomCode)
+}
+
+// Limiter allows a fixed number of messages in any window of time
+type Limiter struct {
+	limit  int
+	window time.Duration
+	sent   []time.Time
+	mu     sync.Mutex
+}
+
+// Allow reports whether another message may be sent now, and counts it if so
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	recent := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+	l.sent = recent
+
+	if len(l.sent) >= l.limit {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
@@ -3,6 +3,7 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
@@ -15,12 +16,29 @@ type GameCompletedEvent struct {
 	RoomCode        string    `json:"room_code"`
 	Player1Name     string    `json:"player1_name"`
 	Player2Name     string    `json:"player2_name"`
+	Player1ID       string    `json:"player1_id,omitempty"` // account or guest IDs, see game.Player.Identity
+	Player2ID       string    `json:"player2_id,omitempty"`
 	Winner          int       `json:"winner"` // 1, 2, or 0 (draw)
+	Reason          string    `json:"reason"` // how the game ended, e.g. "connect", "resign" or "timeout"
 	IsBotGame       bool      `json:"is_bot_game"`
+	Rated           bool      `json:"rated"` // only rated games change player ratings
 	DurationSeconds int64     `json:"duration_seconds"`
 	Timestamp       time.Time `json:"timestamp"`
 }
 
+// AccountUpgradedEvent records a guest registering an account, so the guest's
+// past games can be attributed to the account
+type AccountUpgradedEvent struct {
+	Type      string    `json:"type"`
+	GuestID   string    `json:"guest_id"`
+	AccountID string    `json:"account_id"`
+	Username  string    `json:"username"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ErrDisabled is returned for events that cannot be published because Kafka is not connected
+var ErrDisabled = errors.New("kafka producer is not connected")
+
 // KafkaProducer handles publishing events to Kafka
 type KafkaProducer struct {
 	writer  *kafka.Writer
@@ -78,24 +96,42 @@ func (p *KafkaProducer) PublishGameCompleted(event GameCompletedEvent) {
 	event.Type = "game_completed"
 	event.Timestamp = time.Now()
 
+	if err := p.publish(event.RoomCode, event); err != nil {
+		log.Printf("Error publishing to Kafka: %v", err)
+	} else {
+		log.Printf("Published game_completed event for room %s", event.RoomCode)
+	}
+}
+
+// PublishAccountUpgraded publishes an account upgraded event. Unlike game
+// events it reports failures, so the caller can keep the upgrade and retry.
+func (p *KafkaProducer) PublishAccountUpgraded(event AccountUpgradedEvent) error {
+	if p == nil || !p.enabled {
+		return ErrDisabled
+	}
+
+	event.Type = "account_upgraded"
+	event.Timestamp = time.Now()
+
+	if err := p.publish(event.AccountID, event); err != nil {
+		return err
+	}
+	log.Printf("Published account_upgraded event for account %s", event.AccountID)
+	return nil
+}
+
+func (p *KafkaProducer) publish(key string, event any) error {
 	data, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Error marshaling game event: %v", err)
-		return
+		return err
 	}
 
-	err = p.writer.WriteMessages(context.Background(),
+	return p.writer.WriteMessages(context.Background(),
 		kafka.Message{
-			Key:   []byte(event.RoomCode),
+			Key:   []byte(key),
 			Value: data,
 		},
 	)
-
-	if err != nil {
-		log.Printf("Error publishing to Kafka: %v", err)
-	} else {
-		log.Printf("Published game_completed event for room %s", event.RoomCode)
-	}
 }
 
 // Close closes the Kafka writer
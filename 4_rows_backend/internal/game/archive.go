@@ -0,0 +1,143 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"4_rows_backend/internal/storage"
+)
+
+// GameRecord is a finished game as kept in the archive
+type GameRecord struct {
+	ID          string
+	RoomCode    string
+	Variant     Variant
+	Dimensions  Dimensions
+	Players     [2]string // player names
+	Accounts    [2]string // account IDs of the players, empty for guests
+	Guests      [2]string // guest IDs, kept after a guest registers
+	Winner      int
+	Reason      EndReason
+	IsBotGame   bool
+	BotLevel    string
+	Rated       bool
+	TimeControl TimeControl
+	Start       string // position notation of a seeded start, empty for the variant's own
+	Moves       []Move
+	StartedAt   time.Time // when the first move was played
+	EndedAt     time.Time
+}
+
+// Identity returns the ID a player's game is attributed to, see Player.Identity
+func (g *GameRecord) Identity(playerNum int) string {
+	return Player{AccountID: g.Accounts[playerNum-1], GuestID: g.Guests[playerNum-1]}.Identity()
+}
+
+// StartingPosition returns the position the game started from
+func (g *GameRecord) StartingPosition() Position {
+	if g.Start != "" {
+		if pos, err := ParsePosition(g.Start); err == nil {
+			return pos
+		}
+	}
+	return Position{Variant: g.Variant, Board: RulesFor(g.Variant).NewBoard(g.Dimensions), ToMove: 1}
+}
+
+// archiveGame stores a finished game once, so it can be replayed after its room is gone
+func (rm *RoomManager) archiveGame(room *Room) {
+	if rm.storage == nil {
+		return
+	}
+
+	room.mu.Lock()
+	if !room.GameOver || room.archived {
+		room.mu.Unlock()
+		return
+	}
+	room.archived = true
+
+	dims := room.Board.Dimensions()
+	data := &storage.GameData{
+		ID:             room.GameID,
+		RoomCode:       room.Code,
+		Variant:        string(room.Variant),
+		Rows:           dims.Rows,
+		Cols:           dims.Cols,
+		Connect:        dims.Connect,
+		Player1Name:    room.Players[0].Name,
+		Player2Name:    room.Players[1].Name,
+		Player1Account: room.Players[0].AccountID,
+		Player2Account: room.Players[1].AccountID,
+		Player1Guest:   room.Players[0].GuestID,
+		Player2Guest:   room.Players[1].GuestID,
+		Winner:         room.Winner,
+		Reason:         string(room.EndReason),
+		IsBotGame:      room.IsBotGame,
+		BotLevel:       room.BotLevel,
+		Rated:          room.Rated,
+		TimeControl: storage.TimeControlData{
+			Initial:   room.clock.Control.Initial,
+			Increment: room.clock.Control.Increment,
+			PerMove:   room.clock.Control.PerMove,
+		},
+		Start:   room.startPosition,
+		Moves:   make([]storage.MoveData, len(room.Moves)),
+		EndedAt: time.Now(),
+	}
+	for i, move := range room.Moves {
+		data.Moves[i] = moveData(i+1, move)
+	}
+	room.mu.Unlock()
+
+	data.StartedAt = data.EndedAt
+	if len(data.Moves) > 0 {
+		data.StartedAt = data.Moves[0].PlayedAt
+	}
+
+	if err := rm.storage.SaveGame(data); err != nil {
+		log.Printf("Error archiving game %s of room %s: %v", data.ID, data.RoomCode, err)
+	}
+}
+
+// GetGameRecord returns an archived game by ID
+func (rm *RoomManager) GetGameRecord(id string) (*GameRecord, error) {
+	if rm.storage == nil {
+		return nil, ErrGameNotFound
+	}
+
+	data, err := rm.storage.GetGame(id)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrGameNotFound
+	}
+
+	record := &GameRecord{
+		ID:         data.ID,
+		RoomCode:   data.RoomCode,
+		Variant:    RulesFor(Variant(data.Variant)).Variant(),
+		Dimensions: Dimensions{Rows: data.Rows, Cols: data.Cols, Connect: data.Connect},
+		Players:    [2]string{data.Player1Name, data.Player2Name},
+		Accounts:   [2]string{data.Player1Account, data.Player2Account},
+		Guests:     [2]string{data.Player1Guest, data.Player2Guest},
+		Winner:     data.Winner,
+		Reason:     EndReason(data.Reason),
+		IsBotGame:  data.IsBotGame,
+		BotLevel:   data.BotLevel,
+		Rated:      data.Rated,
+		TimeControl: TimeControl{
+			Initial:   data.TimeControl.Initial,
+			Increment: data.TimeControl.Increment,
+			PerMove:   data.TimeControl.PerMove,
+		},
+		Start:     data.Start,
+		Moves:     make([]Move, len(data.Moves)),
+		StartedAt: data.StartedAt,
+		EndedAt:   data.EndedAt,
+	}
+	for i, m := range data.Moves {
+		record.Moves[i] = moveFromData(m)
+	}
+	return record, nil
+}
@@ -0,0 +1,51 @@
+package game
+
+import "math/bits"
+
+// bitset is a 128 bit mask, large enough for the biggest supported board
+type bitset struct {
+	lo, hi uint64
+}
+
+func bit(i int) bitset {
+	if i < 64 {
+		return bitset{lo: 1 << uint(i)}
+	}
+	return bitset{hi: 1 << uint(i-64)}
+}
+
+func (a bitset) and(b bitset) bitset {
+	return bitset{a.lo & b.lo, a.hi & b.hi}
+}
+
+func (a bitset) or(b bitset) bitset {
+	return bitset{a.lo | b.lo, a.hi | b.hi}
+}
+
+func (a bitset) andNot(b bitset) bitset {
+	return bitset{a.lo &^ b.lo, a.hi &^ b.hi}
+}
+
+func (a bitset) isZero() bool {
+	return a.lo == 0 && a.hi == 0
+}
+
+func (a bitset) onesCount() int {
+	return bits.OnesCount64(a.lo) + bits.OnesCount64(a.hi)
+}
+
+// shr shifts towards bit 0
+func (a bitset) shr(n uint) bitset {
+	if n >= 64 {
+		return bitset{lo: a.hi >> (n - 64)}
+	}
+	return bitset{lo: a.lo>>n | a.hi<<(64-n), hi: a.hi >> n}
+}
+
+// shl shifts away from bit 0
+func (a bitset) shl(n uint) bitset {
+	if n >= 64 {
+		return bitset{hi: a.lo << (n - 64)}
+	}
+	return bitset{lo: a.lo << n, hi: a.hi<<n | a.lo>>(64-n)}
+}
@@ -1,12 +1,62 @@
 package game
 
+import (
+	"errors"
+	"fmt"
+)
+
 const (
-	Rows = 6
-	Cols = 7
+	// Dimensions of the classic board
+	DefaultRows    = 6
+	DefaultCols    = 7
+	DefaultConnect = 4
+
+	MinRows    = 4
+	MaxRows    = 10
+	MinCols    = 4
+	MaxCols    = 10
+	MinConnect = 3
 )
 
+var ErrInvalidDimensions = errors.New("invalid board dimensions")
+
+// Dimensions describes the size of a board and how many discs in a row win
+type Dimensions struct {
+	Rows    int
+	Cols    int
+	Connect int
+}
+
+// ClassicDimensions is the standard 7 wide, 6 high connect four
+var ClassicDimensions = Dimensions{Rows: DefaultRows, Cols: DefaultCols, Connect: DefaultConnect}
+
+// Validate checks that the board fits in a bitboard and that a line of Connect discs fits on it
+func (d Dimensions) Validate() error {
+	if d.Rows < MinRows || d.Rows > MaxRows {
+		return fmt.Errorf("%w: height must be between %d and %d", ErrInvalidDimensions, MinRows, MaxRows)
+	}
+	if d.Cols < MinCols || d.Cols > MaxCols {
+		return fmt.Errorf("%w: width must be between %d and %d", ErrInvalidDimensions, MinCols, MaxCols)
+	}
+	if d.Connect < MinConnect || d.Connect > max(d.Rows, d.Cols) {
+		return fmt.Errorf("%w: connect must be between %d and %d", ErrInvalidDimensions, MinConnect, max(d.Rows, d.Cols))
+	}
+	return nil
+}
+
+// Board is a bitboard: one mask of discs per player plus the height of every column.
+//
+// Column c uses bits c*(rows+1) to c*(rows+1)+rows-1, bottom cell first. The extra
+// bit on top of each column stays empty so shifted masks never wrap into the next one.
+// Boards must be created with NewBoard or BoardFromGrid.
 type Board struct {
-	Grid [Rows][Cols]int
+	discs   [2]bitset
+	heights [MaxCols]int
+	rows    int
+	cols    int
+	connect int
+	moves   int
+	hash    uint64
 }
 
 type CellPos struct {
@@ -14,54 +64,318 @@ type CellPos struct {
 	Col int
 }
 
+// NewBoard returns an empty board; the dimensions must be valid
+func NewBoard(d Dimensions) Board {
+	return Board{
+		rows:    d.Rows,
+		cols:    d.Cols,
+		connect: d.Connect,
+		hash:    dimensionsKey(d),
+	}
+}
+
+// BoardFromGrid builds a board from a grid of player numbers, row 0 being the top
+func BoardFromGrid(grid [][]int, connect int) (Board, error) {
+	d := Dimensions{Rows: len(grid), Connect: connect}
+	if len(grid) > 0 {
+		d.Cols = len(grid[0])
+	}
+	if err := d.Validate(); err != nil {
+		return Board{}, err
+	}
+	for _, row := range grid {
+		if len(row) != d.Cols {
+			return Board{}, fmt.Errorf("%w: rows have different lengths", ErrInvalidDimensions)
+		}
+	}
+
+	b := NewBoard(d)
+	for c := 0; c < d.Cols; c++ {
+		for r := d.Rows - 1; r >= 0; r-- {
+			player := grid[r][c]
+			if player != 1 && player != 2 {
+				break
+			}
+			b.Drop(c, player)
+		}
+	}
+	return b, nil
+}
+
+// Dimensions returns the size of the board and its connect length
+func (b *Board) Dimensions() Dimensions {
+	return Dimensions{Rows: b.rows, Cols: b.cols, Connect: b.connect}
+}
+
+// Rows returns the height of the board
+func (b *Board) Rows() int {
+	return b.rows
+}
+
+// Cols returns the width of the board
+func (b *Board) Cols() int {
+	return b.cols
+}
+
+// Connect returns how many discs in a row are needed to win
+func (b *Board) Connect() int {
+	return b.connect
+}
+
+// IsClassic reports whether this is a standard 7x6 connect four board
+func (b *Board) IsClassic() bool {
+	return b.Dimensions() == ClassicDimensions
+}
+
+// Grid returns the board as player numbers per cell, row 0 being the top
+func (b *Board) Grid() [][]int {
+	grid := make([][]int, b.rows)
+	for r := range grid {
+		grid[r] = make([]int, b.cols)
+		for c := range grid[r] {
+			grid[r][c] = b.Cell(r, c)
+		}
+	}
+	return grid
+}
+
+// Cell returns the player occupying a cell, or 0 if it is empty
+func (b *Board) Cell(row, col int) int {
+	if row < 0 || row >= b.rows || col < 0 || col >= b.cols {
+		return 0
+	}
+	cell := bit(b.cellIndex(row, col))
+	switch {
+	case !b.discs[0].and(cell).isZero():
+		return 1
+	case !b.discs[1].and(cell).isZero():
+		return 2
+	}
+	return 0
+}
+
+// CanPlay reports whether a disc can still be dropped into the column
+func (b *Board) CanPlay(column int) bool {
+	return column >= 0 && column < b.cols && b.heights[column] < b.rows
+}
+
+// Height returns the number of discs in a column
+func (b *Board) Height(column int) int {
+	return b.heights[column]
+}
+
+// MoveCount returns the number of discs on the board
+func (b *Board) MoveCount() int {
+	return b.moves
+}
+
+// DiscCount returns the number of discs a player has on the board
+func (b *Board) DiscCount(player int) int {
+	return b.discs[player-1].onesCount()
+}
+
+// Bitboards returns the raw disc masks of player 1 and player 2, laid out as
+// described on Board. Only boards whose masks fit in 64 bits, such as the
+// classic 7x6, can be represented this way.
+func (b *Board) Bitboards() (uint64, uint64) {
+	return b.discs[0].lo, b.discs[1].lo
+}
+
+// Hash returns the Zobrist hash of the position, maintained incrementally by Drop and Undo.
+// Boards of different dimensions never share a hash for the same discs.
+func (b *Board) Hash() uint64 {
+	return b.hash
+}
+
 func (b *Board) Drop(column int, player int) (int, bool) {
-	if column < 0 || column >= Cols {
+	if !b.CanPlay(column) || (player != 1 && player != 2) {
 		return -1, false
 	}
 
-	for r := Rows - 1; r >= 0; r-- {
-		if b.Grid[r][column] == 0 {
-			b.Grid[r][column] = player
-			return r, true
-		}
+	row := b.rows - 1 - b.heights[column]
+	index := b.cellIndex(row, column)
+	b.discs[player-1] = b.discs[player-1].or(bit(index))
+	b.hash ^= zobristKeys[player-1][index]
+	b.heights[column]++
+	b.moves++
+	return row, true
+}
+
+// Undo removes the top disc of a column, returning false if the column is empty
+func (b *Board) Undo(column int) bool {
+	if column < 0 || column >= b.cols || b.heights[column] == 0 {
+		return false
 	}
-	return -1, false
+
+	b.heights[column]--
+	b.moves--
+	row := b.rows - 1 - b.heights[column]
+	index := b.cellIndex(row, column)
+	b.hash ^= zobristKeys[b.Cell(row, column)-1][index]
+	b.discs[0] = b.discs[0].andNot(bit(index))
+	b.discs[1] = b.discs[1].andNot(bit(index))
+	return true
+}
+
+// IsWinningMove reports whether dropping into the column would connect a line for player
+func (b *Board) IsWinningMove(column int, player int) bool {
+	if !b.CanPlay(column) {
+		return false
+	}
+	row := b.rows - 1 - b.heights[column]
+	return b.hasLine(b.discs[player-1].or(bit(b.cellIndex(row, column))))
+}
+
+// HasWon reports whether player has a winning line anywhere on the board
+func (b *Board) HasWon(player int) bool {
+	return b.hasLine(b.discs[player-1])
 }
 
 func (b *Board) CheckWin(row, col, player int) (bool, []CellPos) {
+	if player != 1 && player != 2 || !b.hasLine(b.discs[player-1]) {
+		return false, nil
+	}
+
 	dirs := [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
 
 	for _, d := range dirs {
 		cells := []CellPos{{row, col}}
 
-		for i := 1; i < 4; i++ {
+		for i := 1; i < b.connect; i++ {
 			r, c := row+d[0]*i, col+d[1]*i
-			if r < 0 || r >= Rows || c < 0 || c >= Cols || b.Grid[r][c] != player {
+			if b.Cell(r, c) != player {
 				break
 			}
 			cells = append(cells, CellPos{r, c})
 		}
 
-		for i := 1; i < 4; i++ {
+		for i := 1; i < b.connect; i++ {
 			r, c := row-d[0]*i, col-d[1]*i
-			if r < 0 || r >= Rows || c < 0 || c >= Cols || b.Grid[r][c] != player {
+			if b.Cell(r, c) != player {
 				break
 			}
 			cells = append(cells, CellPos{r, c})
 		}
 
-		if len(cells) >= 4 {
+		if len(cells) >= b.connect {
 			return true, cells
 		}
 	}
 	return false, nil
 }
 
+// CanPop reports whether player owns the bottom disc of the column
+func (b *Board) CanPop(column int, player int) bool {
+	return column >= 0 && column < b.cols && b.heights[column] > 0 && b.Cell(b.rows-1, column) == player
+}
+
+// Pop removes the bottom disc of a column, letting the discs above it fall one row,
+// and returns the player who owned it
+func (b *Board) Pop(column int) (int, bool) {
+	if column < 0 || column >= b.cols || b.heights[column] == 0 {
+		return 0, false
+	}
+
+	player := b.Cell(b.rows-1, column)
+	mask := b.columnMask(column)
+	b.hash ^= b.columnHash(column)
+	for p := range b.discs {
+		fallen := b.discs[p].and(mask).shr(1).and(mask)
+		b.discs[p] = b.discs[p].andNot(mask).or(fallen)
+	}
+	b.heights[column]--
+	b.moves--
+	b.hash ^= b.columnHash(column)
+	return player, true
+}
+
+// Unpop takes back a Pop, pushing a disc of player back under the column
+func (b *Board) Unpop(column int, player int) bool {
+	if column < 0 || column >= b.cols || b.heights[column] >= b.rows || (player != 1 && player != 2) {
+		return false
+	}
+
+	mask := b.columnMask(column)
+	b.hash ^= b.columnHash(column)
+	for p := range b.discs {
+		raised := b.discs[p].and(mask).shl(1)
+		b.discs[p] = b.discs[p].andNot(mask).or(raised)
+	}
+	b.discs[player-1] = b.discs[player-1].or(bit(b.cellIndex(b.rows-1, column)))
+	b.heights[column]++
+	b.moves++
+	b.hash ^= b.columnHash(column)
+	return true
+}
+
+// WinningCells returns a line of player's discs anywhere on the board, or nil if there is none
+func (b *Board) WinningCells(player int) []CellPos {
+	if player != 1 && player != 2 || !b.hasLine(b.discs[player-1]) {
+		return nil
+	}
+	for r := 0; r < b.rows; r++ {
+		for c := 0; c < b.cols; c++ {
+			if b.Cell(r, c) != player {
+				continue
+			}
+			if won, cells := b.CheckWin(r, c, player); won {
+				return cells
+			}
+		}
+	}
+	return nil
+}
+
+// PositionKey identifies the position together with the player to move, for repetition checks
+func (b *Board) PositionKey(toMove int) uint64 {
+	if toMove == 2 {
+		return b.hash ^ secondPlayerKey
+	}
+	return b.hash
+}
+
 func (b *Board) IsDraw() bool {
-	for c := 0; c < Cols; c++ {
-		if b.Grid[0][c] == 0 {
-			return false
+	return b.moves == b.rows*b.cols
+}
+
+func (b *Board) cellIndex(row, col int) int {
+	return col*(b.rows+1) + b.rows - 1 - row
+}
+
+// columnMask covers the playable cells of a column
+func (b *Board) columnMask(col int) bitset {
+	var mask bitset
+	for r := 0; r < b.rows; r++ {
+		mask = mask.or(bit(b.cellIndex(r, col)))
+	}
+	return mask
+}
+
+// columnHash is the part of the hash contributed by the discs in a column
+func (b *Board) columnHash(col int) uint64 {
+	var h uint64
+	base := col * (b.rows + 1)
+	for i := base; i < base+b.heights[col]; i++ {
+		if !b.discs[0].and(bit(i)).isZero() {
+			h ^= zobristKeys[0][i]
+		} else {
+			h ^= zobristKeys[1][i]
 		}
 	}
-	return true
+	return h
+}
+
+// hasLine checks all four directions at once by and-ing the mask with shifted copies of itself
+func (b *Board) hasLine(mask bitset) bool {
+	stride := uint(b.rows + 1)
+	for _, shift := range [4]uint{1, stride, stride + 1, stride - 1} {
+		line := mask
+		for i := uint(1); i < uint(b.connect) && !line.isZero(); i++ {
+			line = line.and(mask.shr(shift * i))
+		}
+		if !line.isZero() {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,157 @@
+package game
+
+import "testing"
+
+// sampleGame is a full drawn game, so every benchmark touches every cell
+var sampleGame = []int{3, 2, 3, 1, 2, 6, 5, 3, 3, 4, 6, 5, 4, 3, 2, 2, 0, 2, 2, 4, 3, 1, 1, 1, 1, 5, 0, 5, 5, 4, 0, 5, 6, 4, 6, 0, 4, 1, 0, 6, 0, 6}
+
+var sinkBoard Board
+
+// playColumns drops discs in the given columns, players alternating from player 1.
+// It fails the test if a drop is refused or a line is completed before the last one.
+func playColumns(t *testing.T, d Dimensions, columns []int) (Board, bool, []CellPos) {
+	t.Helper()
+	board := NewBoard(d)
+	for m, col := range columns {
+		player := m%2 + 1
+		predicted := board.IsWinningMove(col, player)
+		row, ok := board.Drop(col, player)
+		if !ok {
+			t.Fatalf("move %d: column %d is full", m+1, col)
+		}
+		won, cells := board.CheckWin(row, col, player)
+		if won != predicted || won != board.HasWon(player) {
+			t.Fatalf("move %d: CheckWin %v, IsWinningMove %v, HasWon %v disagree", m+1, won, predicted, board.HasWon(player))
+		}
+		if m == len(columns)-1 {
+			return board, won, cells
+		}
+		if won {
+			t.Fatalf("move %d completed a line early", m+1)
+		}
+	}
+	return board, false, nil
+}
+
+func TestBoardCheckWin(t *testing.T) {
+	tests := []struct {
+		name    string
+		dims    Dimensions
+		columns []int
+		won     bool
+	}{
+		{"horizontal", ClassicDimensions, []int{0, 0, 1, 1, 2, 2, 3}, true},
+		{"vertical", ClassicDimensions, []int{0, 1, 0, 1, 0, 1, 0}, true},
+		{"rising diagonal", ClassicDimensions, []int{0, 1, 1, 2, 2, 3, 2, 3, 3, 5, 3}, true},
+		{"falling diagonal", ClassicDimensions, []int{6, 5, 5, 4, 4, 3, 4, 3, 3, 1, 3}, true},
+		{"three in a row", ClassicDimensions, []int{0, 0, 1, 1, 2, 6}, false},
+		{"gap in the row", ClassicDimensions, []int{0, 0, 1, 1, 3, 3, 4}, false},
+		{"no wrap between columns", ClassicDimensions, []int{6, 0, 0, 0, 0, 5, 0, 5, 0, 5, 1}, false},
+		{"connect three", Dimensions{Rows: 4, Cols: 5, Connect: 3}, []int{0, 0, 1, 1, 2}, true},
+		{"connect five needs five", Dimensions{Rows: 6, Cols: 9, Connect: 5}, []int{0, 0, 1, 1, 2, 2, 3}, false},
+		{"connect five", Dimensions{Rows: 6, Cols: 9, Connect: 5}, []int{0, 0, 1, 1, 2, 2, 3, 3, 4}, true},
+		{"tallest board", Dimensions{Rows: 10, Cols: 10, Connect: 4}, []int{9, 8, 9, 8, 9, 8, 9}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, won, cells := playColumns(t, tt.dims, tt.columns)
+			if won != tt.won {
+				t.Fatalf("won = %v, want %v", won, tt.won)
+			}
+			if won && len(cells) < tt.dims.Connect {
+				t.Errorf("got %d winning cells, want at least %d", len(cells), tt.dims.Connect)
+			}
+		})
+	}
+}
+
+func TestBoardDraw(t *testing.T) {
+	board, won, _ := playColumns(t, ClassicDimensions, sampleGame)
+	if won {
+		t.Fatal("sample game should not have a winner")
+	}
+	if !board.IsDraw() {
+		t.Error("full board should be a draw")
+	}
+	if board.MoveCount() != len(sampleGame) {
+		t.Errorf("MoveCount = %d, want %d", board.MoveCount(), len(sampleGame))
+	}
+}
+
+func TestBoardUndoRestoresPosition(t *testing.T) {
+	empty := NewBoard(ClassicDimensions)
+	board := NewBoard(ClassicDimensions)
+	for m, col := range sampleGame {
+		board.Drop(col, m%2+1)
+	}
+	for m := len(sampleGame) - 1; m >= 0; m-- {
+		if !board.Undo(sampleGame[m]) {
+			t.Fatalf("undo of move %d failed", m+1)
+		}
+	}
+	if board != empty {
+		t.Errorf("board after undoing every move differs from an empty one")
+	}
+}
+
+func TestBoardFromGrid(t *testing.T) {
+	board := NewBoard(ClassicDimensions)
+	for m, col := range sampleGame[:20] {
+		board.Drop(col, m%2+1)
+	}
+
+	rebuilt, err := BoardFromGrid(board.Grid(), DefaultConnect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.Hash() != board.Hash() || rebuilt.MoveCount() != board.MoveCount() {
+		t.Errorf("board rebuilt from its grid differs from the original")
+	}
+}
+
+func BenchmarkDropUndo(b *testing.B) {
+	b.ReportAllocs()
+	board := NewBoard(ClassicDimensions)
+	for i := 0; i < b.N; i++ {
+		for m, col := range sampleGame {
+			board.Drop(col, m%2+1)
+		}
+		for m := len(sampleGame) - 1; m >= 0; m-- {
+			board.Undo(sampleGame[m])
+		}
+	}
+}
+
+func BenchmarkDropCheckWin(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		board := NewBoard(ClassicDimensions)
+		for m, col := range sampleGame {
+			row, _ := board.Drop(col, m%2+1)
+			board.CheckWin(row, col, m%2+1)
+		}
+	}
+}
+
+func BenchmarkIsDraw(b *testing.B) {
+	board := NewBoard(ClassicDimensions)
+	for m, col := range sampleGame[:len(sampleGame)-1] {
+		board.Drop(col, m%2+1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		board.IsDraw()
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	board := NewBoard(ClassicDimensions)
+	for m, col := range sampleGame[:20] {
+		board.Drop(col, m%2+1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sinkBoard = board
+	}
+}
@@ -0,0 +1,118 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	MaxInitialTime = 2 * time.Hour
+	MaxIncrement   = time.Minute
+	MinPerMoveTime = 5 * time.Second
+	MaxPerMoveTime = 10 * time.Minute
+)
+
+// TimeControl is how much thinking time each player gets. Either a budget
+// for the whole game, topped up by Increment after every move (3 minutes + 2
+// seconds), or a fixed PerMove limit that is reset for every move. The zero
+// value means the game is untimed.
+type TimeControl struct {
+	Initial   time.Duration
+	Increment time.Duration
+	PerMove   time.Duration
+}
+
+// Enabled reports whether the game is played on the clock
+func (tc TimeControl) Enabled() bool {
+	return tc.Initial > 0 || tc.PerMove > 0
+}
+
+// Validate checks that exactly one kind of time control is set and that it is within limits
+func (tc TimeControl) Validate() error {
+	switch {
+	case tc == TimeControl{}:
+		return nil
+	case tc.Initial < 0 || tc.Increment < 0 || tc.PerMove < 0:
+		return fmt.Errorf("%w: times cannot be negative", ErrInvalidTimeControl)
+	case tc.PerMove > 0 && (tc.Initial > 0 || tc.Increment > 0):
+		return fmt.Errorf("%w: per-move time cannot be combined with a game budget", ErrInvalidTimeControl)
+	case tc.PerMove > 0 && (tc.PerMove < MinPerMoveTime || tc.PerMove > MaxPerMoveTime):
+		return fmt.Errorf("%w: per-move time must be between %s and %s", ErrInvalidTimeControl, MinPerMoveTime, MaxPerMoveTime)
+	case tc.PerMove == 0 && (tc.Initial <= 0 || tc.Initial > MaxInitialTime):
+		return fmt.Errorf("%w: initial time must be positive and at most %s", ErrInvalidTimeControl, MaxInitialTime)
+	case tc.Increment > MaxIncrement:
+		return fmt.Errorf("%w: increment cannot exceed %s", ErrInvalidTimeControl, MaxIncrement)
+	}
+	return nil
+}
+
+func (tc TimeControl) startingTime() time.Duration {
+	if tc.PerMove > 0 {
+		return tc.PerMove
+	}
+	return tc.Initial
+}
+
+// ClockSnapshot is the time left on both clocks at one instant
+type ClockSnapshot struct {
+	Remaining [2]time.Duration
+	Running   int // player whose clock is running, 0 while stopped
+}
+
+// Clock is a server-authoritative chess clock. Only the running player's time
+// goes down; it is charged when they move or when the room checks for a flag.
+type Clock struct {
+	Control   TimeControl
+	remaining [2]time.Duration
+	running   int
+	since     time.Time
+}
+
+// NewClock returns a stopped clock with the starting time on both sides
+func NewClock(tc TimeControl) Clock {
+	start := tc.startingTime()
+	return Clock{Control: tc, remaining: [2]time.Duration{start, start}}
+}
+
+// Start runs player's clock from now on
+func (c *Clock) Start(player int, now time.Time) {
+	c.running = player
+	c.since = now
+}
+
+// Stop charges the running player and stops the clock
+func (c *Clock) Stop(now time.Time) {
+	if c.running != 0 {
+		c.remaining[c.running-1] = c.Remaining(c.running, now)
+		c.running = 0
+	}
+}
+
+// Remaining returns the time player has left at now, which may be negative once they flagged
+func (c *Clock) Remaining(player int, now time.Time) time.Duration {
+	left := c.remaining[player-1]
+	if c.running == player {
+		left -= now.Sub(c.since)
+	}
+	return left
+}
+
+// Moved charges the player who just moved, adds their increment and starts next's clock
+func (c *Clock) Moved(player int, next int, now time.Time) {
+	left := c.Remaining(player, now)
+	if c.Control.PerMove > 0 {
+		left = c.Control.PerMove
+	} else {
+		left += c.Control.Increment
+	}
+	c.remaining[player-1] = left
+	c.Start(next, now)
+}
+
+// Snapshot returns the remaining times at now
+func (c *Clock) Snapshot(now time.Time) ClockSnapshot {
+	return ClockSnapshot{
+		Remaining: [2]time.Duration{max(c.Remaining(1, now), 0), max(c.Remaining(2, now), 0)},
+		Running:   c.running,
+	}
+}
@@ -0,0 +1,91 @@
+package game
+
+// finish ends the game and stops the clock. Callers hold r.mu.
+func (r *Room) finish(winner int, reason EndReason) {
+	r.GameOver = true
+	r.Winner = winner
+	r.EndReason = reason
+	r.DrawOfferedBy = 0
+	r.TakebackBy = 0
+	r.stopClock()
+}
+
+// active reports whether moves can still be made. Callers hold r.mu.
+func (r *Room) active() bool {
+	return r.GameStarted && !r.GameOver
+}
+
+// Resign ends the game as a loss for playerNum
+func (r *Room) Resign(playerNum int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return ErrGameNotActive
+	}
+	r.finish(3-playerNum, EndResign)
+	return nil
+}
+
+// OfferDraw records a draw offer from playerNum. If the opponent already
+// offered one the offers cross and the game is drawn, reported as agreed.
+func (r *Room) OfferDraw(playerNum int) (agreed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return false, ErrGameNotActive
+	}
+	switch r.DrawOfferedBy {
+	case playerNum:
+		return false, ErrDrawOffered
+	case 3 - playerNum:
+		r.finish(0, EndAgreement)
+		return true, nil
+	}
+	r.DrawOfferedBy = playerNum
+	return false, nil
+}
+
+// AcceptDraw draws the game if the opponent of playerNum has offered it
+func (r *Room) AcceptDraw(playerNum int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return ErrGameNotActive
+	}
+	if r.DrawOfferedBy != 3-playerNum {
+		return ErrNoDrawOffer
+	}
+	r.finish(0, EndAgreement)
+	return nil
+}
+
+// DeclineDraw turns down the draw offered to playerNum
+func (r *Room) DeclineDraw(playerNum int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return ErrGameNotActive
+	}
+	if r.DrawOfferedBy != 3-playerNum {
+		return ErrNoDrawOffer
+	}
+	r.DrawOfferedBy = 0
+	return nil
+}
+
+// Abandon ends a game in progress as a loss for a player who left and did
+// not come back, returning false if there was no game left to lose
+func (r *Room) Abandon(playerNum int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return false
+	}
+	r.finish(3-playerNum, EndAbandonment)
+	return true
+}
@@ -3,8 +3,25 @@ package game
 import "errors"
 
 var (
-	ErrRoomNotFound = errors.New("room not found")
-	ErrRoomFull     = errors.New("room is full")
-	ErrNotYourTurn  = errors.New("not your turn")
-	ErrInvalidMove  = errors.New("invalid move")
+	ErrRoomNotFound    = errors.New("room not found")
+	ErrRoomFull        = errors.New("room is full")
+	ErrNotYourTurn     = errors.New("not your turn")
+	ErrInvalidMove     = errors.New("invalid move")
+	ErrPositionChanged = errors.New("position changed since the move was chosen")
+	ErrSlotTaken       = errors.New("player slot is no longer available")
+
+	ErrUnknownVariant     = errors.New("unknown variant")
+	ErrInvalidTimeControl = errors.New("invalid time control")
+	ErrTimeUp             = errors.New("time is up")
+
+	ErrGameNotActive = errors.New("game is not in progress")
+	ErrDrawOffered   = errors.New("draw already offered")
+	ErrNoDrawOffer   = errors.New("no draw offer to answer")
+
+	ErrTakebackNotAllowed = errors.New("takebacks are not allowed in rated games")
+	ErrNothingToTakeBack  = errors.New("no move to take back")
+	ErrTakebackRequested  = errors.New("takeback already requested")
+	ErrNoTakebackRequest  = errors.New("no takeback request to answer")
+
+	ErrGameNotFound = errors.New("game not found")
 )
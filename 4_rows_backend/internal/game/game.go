@@ -4,6 +4,7 @@ type GameResult struct {
 	RoomCode string
 	Winner   int
 	Draw     bool
+	Reason   EndReason
 }
 
 func FinishGame(room *Room) GameResult {
@@ -11,5 +12,6 @@ func FinishGame(room *Room) GameResult {
 		RoomCode: room.Code,
 		Winner:   room.Winner,
 		Draw:     room.GameOver && room.Winner == 0,
+		Reason:   room.EndReason,
 	}
 }
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+// LobbyRoom is a public room waiting for an opponent
+type LobbyRoom struct {
+	Code        string
+	Host        string
+	Variant     Variant
+	Dimensions  Dimensions
+	TimeControl TimeControl
+	Rated       bool
+	CreatedAt   time.Time
+}
+
+// SetLobbyHandler registers the function called when a public room opens
+// (open is true) or stops waiting for an opponent. Only the code of a room
+// that is no longer open is set.
+func (rm *RoomManager) SetLobbyHandler(fn func(room LobbyRoom, open bool)) {
+	rm.onLobby = fn
+}
+
+func (rm *RoomManager) notifyLobby(room LobbyRoom, open bool) {
+	if rm.onLobby != nil {
+		rm.onLobby(room, open)
+	}
+}
+
+// OpenRooms returns the public rooms waiting for an opponent, oldest first
+func (rm *RoomManager) OpenRooms() []LobbyRoom {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	var rooms []LobbyRoom
+	for _, room := range rm.rooms {
+		room.mu.Lock()
+		if room.open() {
+			rooms = append(rooms, room.lobbyRoom())
+		}
+		room.mu.Unlock()
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.Before(rooms[j].CreatedAt)
+	})
+	return rooms
+}
+
+// open reports whether the room is listed in the lobby: public, waiting for
+// an opponent and with its host connected. Callers hold r.mu.
+func (r *Room) open() bool {
+	return r.Public && !r.IsBotGame && r.Players[1].ID == "" && r.Players[0].Connected && !r.GameOver
+}
+
+// lobbyRoom describes the room for the lobby. Callers hold r.mu or own the
+// room before it is shared.
+func (r *Room) lobbyRoom() LobbyRoom {
+	return LobbyRoom{
+		Code:        r.Code,
+		Host:        r.Players[0].Name,
+		Variant:     r.Variant,
+		Dimensions:  r.Board.Dimensions(),
+		TimeControl: r.clock.Control,
+		Rated:       r.Rated,
+		CreatedAt:   r.CreatedAt,
+	}
+}
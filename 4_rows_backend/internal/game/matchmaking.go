@@ -0,0 +1,455 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBotFallback is how long a player waits for an opponent before playing the bot
+	DefaultBotFallback = 30 * time.Second
+
+	// DefaultRating is used for players without a rating
+	DefaultRating = 1500
+
+	// A player accepts opponents within RatingWindow of their rating, widening
+	// by RatingWindowGrowth every second in the queue up to MaxRatingWindow
+	RatingWindow       = 100
+	RatingWindowGrowth = 10
+	MaxRatingWindow    = 600
+
+	// sweepInterval is how often waiting players are paired again as their windows widen
+	sweepInterval = time.Second
+)
+
+// MatchRequest is a player looking for an opponent. An empty Variant or a nil
+// TimeControl accepts whatever the opponent asks for.
+type MatchRequest struct {
+	PlayerID    string
+	PlayerName  string
+	AccountID   string
+	GuestID     string
+	Variant     Variant
+	TimeControl *TimeControl
+	Rating      float64 // see Matchmaker.Rating; zero is taken as DefaultRating
+	QueuedAt    time.Time
+}
+
+// Match is a room created for two queued players, Players[0] moving first
+type Match struct {
+	Room    *Room
+	Players [2]MatchRequest
+}
+
+// QueueStats describes the players waiting for and matched in one kind of game
+type QueueStats struct {
+	Queue        string // variant and time control, e.g. "classic 3+2"
+	Waiting      int
+	Matches      int
+	Fallbacks    int // players who waited too long and played the bot
+	AvgWait      time.Duration
+	AvgRatingGap float64
+}
+
+// queueTotals accumulates the statistics of one queue
+type queueTotals struct {
+	matches   int
+	fallbacks int
+	wait      time.Duration // summed over both players of every match
+	ratingGap float64
+}
+
+// Matchmaker pairs waiting players of similar rating, longest waiting first
+type Matchmaker struct {
+	queue       []MatchRequest
+	timers      map[string]*time.Timer
+	sweepTimer  *time.Timer
+	totals      map[string]*queueTotals
+	botFallback time.Duration
+	onFallback  func(req MatchRequest)
+	onMatch     func(match Match)
+	ratings     func(identity string) (float64, bool)
+	mu          sync.Mutex
+}
+
+var matchmaker *Matchmaker
+
+func GetMatchmaker() *Matchmaker {
+	if matchmaker == nil {
+		matchmaker = &Matchmaker{
+			timers:      make(map[string]*time.Timer),
+			totals:      make(map[string]*queueTotals),
+			botFallback: DefaultBotFallback,
+		}
+	}
+	return matchmaker
+}
+
+// SetMatchHandler registers the function called with every room the matchmaker
+// creates. It runs on its own goroutine, so it may wait for locks held by
+// whoever queued the players.
+func (mm *Matchmaker) SetMatchHandler(fn func(match Match)) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.onMatch = fn
+}
+
+// SetBotFallback registers the function called for a player still unmatched
+// after the given time; zero disables the fallback
+func (mm *Matchmaker) SetBotFallback(after time.Duration, fn func(req MatchRequest)) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.botFallback = after
+	mm.onFallback = fn
+}
+
+// SetRatingLookup registers where ratings come from, by player identity.
+// Players it does not know are matched at DefaultRating.
+func (mm *Matchmaker) SetRatingLookup(fn func(identity string) (float64, bool)) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.ratings = fn
+}
+
+// BotFallback returns how long players wait before the fallback, zero if there is none
+func (mm *Matchmaker) BotFallback() time.Duration {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if mm.onFallback == nil {
+		return 0
+	}
+	return mm.botFallback
+}
+
+// Rating returns the rating a player is matched at, by identity (see
+// Player.Identity). The lookup may be slow, so callers should not hold locks.
+func (mm *Matchmaker) Rating(identity string) float64 {
+	mm.mu.Lock()
+	ratings := mm.ratings
+	mm.mu.Unlock()
+
+	if ratings != nil && identity != "" {
+		if rating, ok := ratings(identity); ok {
+			return rating
+		}
+	}
+	return DefaultRating
+}
+
+// FindMatch queues req and pairs it at once if a suitable opponent is waiting.
+// Matches are delivered to the match handler. A player already in the queue
+// is queued again with the new request. A request put back after a failed
+// pairing keeps its QueuedAt, and with it the rating window and the fallback
+// time it has already waited for.
+func (mm *Matchmaker) FindMatch(req MatchRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if req.Rating == 0 {
+		req.Rating = DefaultRating
+	}
+
+	mm.mu.Lock()
+	mm.remove(req.PlayerID)
+	if req.QueuedAt.IsZero() {
+		req.QueuedAt = time.Now()
+	}
+	// Keep the queue in waiting order, longest first
+	at := slices.IndexFunc(mm.queue, func(other MatchRequest) bool {
+		return other.QueuedAt.After(req.QueuedAt)
+	})
+	if at < 0 {
+		at = len(mm.queue)
+	}
+	mm.queue = slices.Insert(mm.queue, at, req)
+	if mm.onFallback != nil && mm.botFallback > 0 {
+		playerID := req.PlayerID
+		wait := max(mm.botFallback-time.Since(req.QueuedAt), 0)
+		mm.timers[playerID] = time.AfterFunc(wait, func() {
+			mm.fallback(playerID)
+		})
+	}
+	mm.mu.Unlock()
+
+	mm.sweep()
+	return nil
+}
+
+// Cancel takes a player out of the queue, reporting whether they were in it
+func (mm *Matchmaker) Cancel(playerID string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.remove(playerID)
+}
+
+// Stats returns the statistics of every queue that has seen players, by name
+func (mm *Matchmaker) Stats() []QueueStats {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	stats := make(map[string]*QueueStats)
+	get := func(queue string) *QueueStats {
+		if stats[queue] == nil {
+			stats[queue] = &QueueStats{Queue: queue}
+		}
+		return stats[queue]
+	}
+
+	for queue, totals := range mm.totals {
+		s := get(queue)
+		s.Matches = totals.matches
+		s.Fallbacks = totals.fallbacks
+		if totals.matches > 0 {
+			s.AvgWait = totals.wait / time.Duration(2*totals.matches)
+			s.AvgRatingGap = totals.ratingGap / float64(totals.matches)
+		}
+	}
+	for _, req := range mm.queue {
+		get(req.queueName()).Waiting++
+	}
+
+	result := make([]QueueStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Queue < result[j].Queue })
+	return result
+}
+
+// sweep pairs every waiting player it can and schedules the next sweep while
+// players are left waiting. The rooms are created after unlocking, as that
+// writes to the database.
+func (mm *Matchmaker) sweep() {
+	mm.mu.Lock()
+	now := time.Now()
+	var pairs [][2]MatchRequest
+	for i := 0; i < len(mm.queue); i++ {
+		j := mm.bestOpponent(i, now)
+		if j < 0 {
+			continue
+		}
+		first, second := mm.queue[i], mm.queue[j]
+		mm.queue = slices.Delete(mm.queue, j, j+1)
+		mm.queue = slices.Delete(mm.queue, i, i+1)
+		mm.stopTimer(first.PlayerID)
+		mm.stopTimer(second.PlayerID)
+		i--
+		pairs = append(pairs, [2]MatchRequest{first, second})
+	}
+
+	if len(mm.queue) > 0 && mm.sweepTimer == nil {
+		mm.sweepTimer = time.AfterFunc(sweepInterval, func() {
+			mm.mu.Lock()
+			mm.sweepTimer = nil
+			mm.mu.Unlock()
+			mm.sweep()
+		})
+	}
+	mm.mu.Unlock()
+
+	var matches []Match
+	for _, pair := range pairs {
+		room, err := createMatchRoom(pair[0], pair[1])
+		if err != nil {
+			// Requests were validated when queued, so this should not happen
+			continue
+		}
+		matches = append(matches, Match{Room: room, Players: pair})
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	mm.mu.Lock()
+	for _, match := range matches {
+		mm.record(match.Room, match.Players[0], match.Players[1], now)
+	}
+	onMatch := mm.onMatch
+	mm.mu.Unlock()
+
+	if onMatch != nil {
+		for _, match := range matches {
+			go onMatch(match)
+		}
+	}
+}
+
+// bestOpponent returns the index of the waiting player after i closest in
+// rating to them whom both windows accept, or -1. Callers hold mm.mu.
+func (mm *Matchmaker) bestOpponent(i int, now time.Time) int {
+	player := mm.queue[i]
+	best, bestGap := -1, math.Inf(1)
+	for j := i + 1; j < len(mm.queue); j++ {
+		other := mm.queue[j]
+		if !player.compatible(other) {
+			continue
+		}
+		gap := math.Abs(player.Rating - other.Rating)
+		if gap > player.window(now) || gap > other.window(now) || gap >= bestGap {
+			continue
+		}
+		best, bestGap = j, gap
+	}
+	return best
+}
+
+// record adds a match to the statistics of its queue. Callers hold mm.mu.
+func (mm *Matchmaker) record(room *Room, first, second MatchRequest, now time.Time) {
+	queue := queueName(room.Variant, &room.clock.Control)
+	totals := mm.totals[queue]
+	if totals == nil {
+		totals = &queueTotals{}
+		mm.totals[queue] = totals
+	}
+	totals.matches++
+	totals.wait += now.Sub(first.QueuedAt) + now.Sub(second.QueuedAt)
+	totals.ratingGap += math.Abs(first.Rating - second.Rating)
+}
+
+// fallback hands a player who waited too long to the fallback function,
+// which takes them out of the queue with TakeFallback
+func (mm *Matchmaker) fallback(playerID string) {
+	mm.mu.Lock()
+	i := mm.index(playerID)
+	if i < 0 {
+		mm.mu.Unlock()
+		return
+	}
+	req := mm.queue[i]
+	onFallback := mm.onFallback
+	mm.mu.Unlock()
+
+	onFallback(req)
+}
+
+// TakeFallback removes a player handed to the fallback function from the
+// queue. It reports false if the request is no longer waiting because the
+// player was matched, cancelled or searched again in the meantime.
+func (mm *Matchmaker) TakeFallback(req MatchRequest) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	i := mm.index(req.PlayerID)
+	if i < 0 || !mm.queue[i].QueuedAt.Equal(req.QueuedAt) {
+		return false
+	}
+	mm.queue = slices.Delete(mm.queue, i, i+1)
+	delete(mm.timers, req.PlayerID)
+
+	queue := req.queueName()
+	if mm.totals[queue] == nil {
+		mm.totals[queue] = &queueTotals{}
+	}
+	mm.totals[queue].fallbacks++
+	return true
+}
+
+// remove drops a player's request and timer. Callers hold mm.mu.
+func (mm *Matchmaker) remove(playerID string) bool {
+	i := mm.index(playerID)
+	if i < 0 {
+		return false
+	}
+	mm.queue = slices.Delete(mm.queue, i, i+1)
+	mm.stopTimer(playerID)
+	return true
+}
+
+// index finds a player's request in the queue, -1 if they are not waiting. Callers hold mm.mu.
+func (mm *Matchmaker) index(playerID string) int {
+	return slices.IndexFunc(mm.queue, func(req MatchRequest) bool {
+		return req.PlayerID == playerID
+	})
+}
+
+// stopTimer cancels a player's fallback. Callers hold mm.mu.
+func (mm *Matchmaker) stopTimer(playerID string) {
+	if timer := mm.timers[playerID]; timer != nil {
+		timer.Stop()
+		delete(mm.timers, playerID)
+	}
+}
+
+// player is who takes the seat when the request is matched
+func (r MatchRequest) player() Player {
+	return Player{ID: r.PlayerID, Name: r.PlayerName, AccountID: r.AccountID, GuestID: r.GuestID}
+}
+
+// Validate checks the variant and time control a player asked for
+func (r MatchRequest) Validate() error {
+	if r.Variant != "" {
+		if _, err := ParseVariant(string(r.Variant)); err != nil {
+			return err
+		}
+	}
+	if r.TimeControl != nil {
+		return r.TimeControl.Validate()
+	}
+	return nil
+}
+
+// window is how far from their own rating the player accepts opponents after waiting until now
+func (r MatchRequest) window(now time.Time) float64 {
+	return min(RatingWindow+RatingWindowGrowth*now.Sub(r.QueuedAt).Seconds(), MaxRatingWindow)
+}
+
+// compatible reports whether two players asked for the same kind of game
+func (r MatchRequest) compatible(other MatchRequest) bool {
+	if r.Variant != "" && other.Variant != "" && r.Variant != other.Variant {
+		return false
+	}
+	if r.TimeControl != nil && other.TimeControl != nil && *r.TimeControl != *other.TimeControl {
+		return false
+	}
+	return true
+}
+
+// queueName is the queue a player waits in, "any" standing for what they left open
+func (r MatchRequest) queueName() string {
+	return queueName(r.Variant, r.TimeControl)
+}
+
+func queueName(variant Variant, tc *TimeControl) string {
+	name := string(variant)
+	if name == "" {
+		name = "any"
+	}
+	switch {
+	case tc == nil:
+		return name + " any"
+	case !tc.Enabled():
+		return name + " untimed"
+	case tc.PerMove > 0:
+		return fmt.Sprintf("%s %ds/move", name, int(tc.PerMove/time.Second))
+	}
+	return fmt.Sprintf("%s %g+%d", name, tc.Initial.Minutes(), int(tc.Increment/time.Second))
+}
+
+// createMatchRoom creates the room of two matched players with the options
+// either of them asked for. Matched games are rated.
+func createMatchRoom(first, second MatchRequest) (*Room, error) {
+	opts := RoomOptions{Variant: first.Variant, Rated: true}
+	if opts.Variant == "" {
+		opts.Variant = second.Variant
+	}
+	if first.TimeControl != nil {
+		opts.TimeControl = *first.TimeControl
+	} else if second.TimeControl != nil {
+		opts.TimeControl = *second.TimeControl
+	}
+
+	rm := GetRoomManager()
+	room, err := rm.CreateRoom(first.player(), opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rm.JoinRoom(room.Code, second.player()); err != nil {
+		rm.RemoveRoom(room.Code)
+		return nil, err
+	}
+	return room, nil
+}
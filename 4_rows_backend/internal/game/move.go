@@ -1,13 +1,55 @@
 package game
 
+import "time"
+
+// MoveKind tells a drop from a pop
+type MoveKind string
+
+const (
+	MoveDrop MoveKind = "drop" // add a disc to the top of a column
+	MovePop  MoveKind = "pop"  // remove your own disc from the bottom of a column (PopOut only)
+)
+
 type Move struct {
+	Kind      MoveKind
 	Column    int
+	Row       int // row of the dropped disc, or the bottom row for a pop
 	PlayerNum int
+	Kept      bool      // Pop Ten: the popped disc was part of a line and left the board
+	PlayedAt  time.Time // server time the move was accepted
+}
+
+// EndReason says how a game finished
+type EndReason string
+
+const (
+	EndConnect     EndReason = "connect"     // a line was completed, or Pop Ten's target reached
+	EndBoardFull   EndReason = "board_full"  // nobody can move any more
+	EndRepetition  EndReason = "repetition"  // the same position occurred three times
+	EndResign      EndReason = "resign"      // the loser gave up
+	EndAgreement   EndReason = "agreement"   // both players agreed to a draw
+	EndTimeout     EndReason = "timeout"     // the loser ran out of time
+	EndAbandonment EndReason = "abandonment" // the loser left and did not come back
+)
+
+// MoveResult is the state of the game right after a move
+type MoveResult struct {
+	Move         Move
+	NextPlayer   int
+	GameOver     bool
+	Winner       int
+	Reason       EndReason
+	WinningCells []CellPos
+	Clock        *ClockSnapshot // nil in untimed games
 }
 
 func ApplyMove(room *Room, move Move) (int, error) {
 	if room == nil {
 		return -1, ErrRoomNotFound
 	}
-	return room.MakeMove(move.Column, move.PlayerNum)
+	result, err := room.Play(move)
+	if err != nil {
+		return -1, err
+	}
+	return result.Move.Row, nil
 }
@@ -0,0 +1,274 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Move sequences write one character per drop: the column counted from 1 on
+// the left, with 0 standing for the tenth column of the widest boards. A pop
+// is the column prefixed with 'p'. Whose move it is follows from the rules,
+// so "4453p4" is a complete PopOut game prefix.
+//
+// Positions are written like chess FEN, as five fields separated by spaces:
+//
+//	7/7/7/3x3/3o3/2xox2 1 classic 4 0-0
+//
+// The rows come first, top to bottom, with x for player 1, o for player 2 and
+// a number for a run of empty cells. Then the player to move, the variant, the
+// connect length and the discs each player has kept in Pop Ten.
+
+var ErrInvalidNotation = errors.New("invalid notation")
+
+const (
+	notationPop     = 'p'
+	notationPlayer1 = 'x'
+	notationPlayer2 = 'o'
+)
+
+// FormatMoves writes moves as a move sequence
+func FormatMoves(moves []Move) string {
+	var sb strings.Builder
+	for _, move := range moves {
+		if move.Kind == MovePop {
+			sb.WriteByte(notationPop)
+		}
+		sb.WriteByte(byte('0' + (move.Column+1)%10))
+	}
+	return sb.String()
+}
+
+// ParseMoves reads a move sequence, ignoring spaces. The moves have no player
+// until they are played, see ReplayMoves.
+func ParseMoves(s string) ([]Move, error) {
+	var moves []Move
+	kind := MoveDrop
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == ' ':
+			continue
+		case ch == notationPop && kind == MoveDrop:
+			kind = MovePop
+			continue
+		case ch < '0' || ch > '9':
+			return nil, fmt.Errorf("%w: unexpected %q at offset %d", ErrInvalidNotation, ch, i)
+		}
+
+		column := int(ch-'0') - 1
+		if column < 0 {
+			column = 9
+		}
+		moves = append(moves, Move{Kind: kind, Column: column})
+		kind = MoveDrop
+	}
+	if kind == MovePop {
+		return nil, fmt.Errorf("%w: pop without a column", ErrInvalidNotation)
+	}
+	return moves, nil
+}
+
+// ReplayMoves plays moves from the starting position of a variant, filling in
+// the player, row and kept flag of each move. The dimensions may be the zero
+// value to use the variant's default board.
+func ReplayMoves(variant Variant, d Dimensions, moves []Move) (Position, []Move, error) {
+	rules := RulesFor(variant)
+	d, err := rules.Dimensions(d)
+	if err != nil {
+		return Position{}, nil, err
+	}
+
+	pos := Position{Variant: rules.Variant(), Board: rules.NewBoard(d), ToMove: 1}
+	state := NewRuleState()
+	played := make([]Move, 0, len(moves))
+	over := false
+	for i, move := range moves {
+		if over {
+			return Position{}, nil, fmt.Errorf("%w: the game is over after move %d", ErrInvalidNotation, i)
+		}
+
+		move.PlayerNum = pos.ToMove
+		result, err := PlayMove(rules, &pos.Board, &state, move)
+		if err != nil {
+			return Position{}, nil, fmt.Errorf("%w: move %d (%s) is not legal", ErrInvalidNotation, i+1, FormatMoves([]Move{move}))
+		}
+
+		played = append(played, result.Move)
+		pos.ToMove = result.NextPlayer
+		over = result.GameOver
+	}
+	pos.Kept = state.Kept
+	return pos, played, nil
+}
+
+// Position is a board together with everything needed to continue the game from it
+type Position struct {
+	Variant Variant
+	Board   Board
+	ToMove  int
+	Kept    [2]int // Pop Ten only
+}
+
+// RuleState returns a fresh rule state for continuing from the position.
+// Repetitions are counted from this position on.
+func (p *Position) RuleState() RuleState {
+	state := NewRuleState()
+	state.Kept = p.Kept
+	return state
+}
+
+// String writes the position in position notation
+func (p Position) String() string {
+	var sb strings.Builder
+	for r := 0; r < p.Board.Rows(); r++ {
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for c := 0; c < p.Board.Cols(); c++ {
+			cell := p.Board.Cell(r, c)
+			if cell == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			if cell == 1 {
+				sb.WriteByte(notationPlayer1)
+			} else {
+				sb.WriteByte(notationPlayer2)
+			}
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+	}
+
+	fmt.Fprintf(&sb, " %d %s %d %d-%d", p.ToMove, p.Variant, p.Board.Connect(), p.Kept[0], p.Kept[1])
+	return sb.String()
+}
+
+// ParsePosition reads a position written in position notation
+func ParsePosition(s string) (Position, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return Position{}, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidNotation, len(fields))
+	}
+
+	var pos Position
+	var err error
+	if pos.Variant, err = ParseVariant(fields[2]); err != nil {
+		return Position{}, err
+	}
+
+	connect, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return Position{}, fmt.Errorf("%w: connect length %q is not a number", ErrInvalidNotation, fields[3])
+	}
+
+	grid, err := parseRows(fields[0])
+	if err != nil {
+		return Position{}, err
+	}
+	pos.Board, err = BoardFromGrid(grid, connect)
+	if err != nil {
+		return Position{}, err
+	}
+	if _, err := RulesFor(pos.Variant).Dimensions(pos.Board.Dimensions()); err != nil {
+		return Position{}, err
+	}
+	if !gridMatches(&pos.Board, grid) {
+		return Position{}, fmt.Errorf("%w: discs cannot float above an empty cell", ErrInvalidNotation)
+	}
+
+	switch fields[1] {
+	case "1":
+		pos.ToMove = 1
+	case "2":
+		pos.ToMove = 2
+	default:
+		return Position{}, fmt.Errorf("%w: player to move must be 1 or 2, got %q", ErrInvalidNotation, fields[1])
+	}
+
+	if _, err := fmt.Sscanf(fields[4], "%d-%d", &pos.Kept[0], &pos.Kept[1]); err != nil {
+		return Position{}, fmt.Errorf("%w: kept discs %q must look like 0-0", ErrInvalidNotation, fields[4])
+	}
+
+	if err := pos.validate(); err != nil {
+		return Position{}, err
+	}
+	return pos, nil
+}
+
+// parseRows reads the board field, row 0 being the top
+func parseRows(field string) ([][]int, error) {
+	var grid [][]int
+	for _, text := range strings.Split(field, "/") {
+		var row []int
+		for i := 0; i < len(text); i++ {
+			switch ch := text[i]; {
+			case ch == notationPlayer1:
+				row = append(row, 1)
+			case ch == notationPlayer2:
+				row = append(row, 2)
+			case ch >= '1' && ch <= '9':
+				end := i + 1
+				for end < len(text) && text[end] >= '0' && text[end] <= '9' {
+					end++
+				}
+				n, _ := strconv.Atoi(text[i:end])
+				if n > MaxCols {
+					return nil, fmt.Errorf("%w: row %d is too wide", ErrInvalidNotation, len(grid)+1)
+				}
+				row = append(row, make([]int, n)...)
+				i = end - 1
+			default:
+				return nil, fmt.Errorf("%w: unexpected %q in row %d", ErrInvalidNotation, ch, len(grid)+1)
+			}
+		}
+		grid = append(grid, row)
+	}
+	return grid, nil
+}
+
+// gridMatches reports whether b holds exactly the discs of grid. BoardFromGrid
+// skips discs above a gap, so a mismatch means the grid had floating discs.
+func gridMatches(b *Board, grid [][]int) bool {
+	for r := range grid {
+		for c := range grid[r] {
+			if b.Cell(r, c) != grid[r][c] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validate checks what the variant's rules imply about a position
+func (p *Position) validate() error {
+	if p.Variant != VariantPopTen && p.Kept != [2]int{} {
+		return fmt.Errorf("%w: only Pop Ten positions have kept discs", ErrInvalidNotation)
+	}
+	if p.Kept[0] < 0 || p.Kept[1] < 0 || p.Kept[0] >= popTenTarget || p.Kept[1] >= popTenTarget {
+		return fmt.Errorf("%w: kept discs must be between 0 and %d", ErrInvalidNotation, popTenTarget-1)
+	}
+
+	// Without pops, the player who moved first has dropped as many discs as
+	// the other or one more. Usually that is player 1, but a bot may start as
+	// player 2, so with equal counts either player can be to move.
+	if rules, ok := RulesFor(p.Variant).(dropRules); ok {
+		extra := p.Board.DiscCount(1) - p.Board.DiscCount(2)
+		if rules.prefill {
+			prefilled := rules.NewBoard(p.Board.Dimensions())
+			extra -= prefilled.DiscCount(1) - prefilled.DiscCount(2)
+		}
+		if extra != 0 && extra != 2*p.ToMove-3 {
+			return fmt.Errorf("%w: disc counts do not match player %d to move", ErrInvalidNotation, p.ToMove)
+		}
+	}
+	return nil
+}
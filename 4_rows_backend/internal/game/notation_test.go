@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMoveSequenceRoundTrip(t *testing.T) {
+	tests := []string{"", "4453", "1234567", "p1p7", "12p34p5", "90"}
+
+	for _, sequence := range tests {
+		moves, err := ParseMoves(sequence)
+		if err != nil {
+			t.Fatalf("ParseMoves(%q): %v", sequence, err)
+		}
+		if got := FormatMoves(moves); got != sequence {
+			t.Errorf("FormatMoves(ParseMoves(%q)) = %q", sequence, got)
+		}
+	}
+}
+
+func TestParseMoves(t *testing.T) {
+	moves, err := ParseMoves("4 p1 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Move{{Kind: MoveDrop, Column: 3}, {Kind: MovePop, Column: 0}, {Kind: MoveDrop, Column: 9}}
+	if len(moves) != len(want) {
+		t.Fatalf("got %d moves, want %d", len(moves), len(want))
+	}
+	for i := range want {
+		if moves[i] != want[i] {
+			t.Errorf("move %d = %+v, want %+v", i+1, moves[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"4a", "p", "pp1", "4-"} {
+		if _, err := ParseMoves(bad); !errors.Is(err, ErrInvalidNotation) {
+			t.Errorf("ParseMoves(%q) error = %v, want ErrInvalidNotation", bad, err)
+		}
+	}
+}
+
+func TestPositionRoundTrip(t *testing.T) {
+	tests := []string{
+		"7/7/7/7/7/7 1 classic 4 0-0",
+		"7/7/7/3x3/3o3/2xox2 2 classic 4 0-0",
+		"7/7/7/7/7/3x3 2 classic 4 0-0",
+		"7/7/7/7/7/7 2 classic 4 0-0", // a bot moving first as player 2
+		"5/5/5/5 1 classic 3 0-0",
+		"7/7/7/7/o4x1/xooo1xx 1 popout 4 0-0",
+		"7/7/7/7/7/7 1 popten 4 3-2",
+	}
+
+	for _, notation := range tests {
+		pos, err := ParsePosition(notation)
+		if err != nil {
+			t.Fatalf("ParsePosition(%q): %v", notation, err)
+		}
+		if got := pos.String(); got != notation {
+			t.Errorf("ParsePosition(%q).String() = %q", notation, got)
+		}
+	}
+}
+
+func TestParsePositionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		notation string
+	}{
+		{"missing fields", "7/7/7/7/7/7 1 classic"},
+		{"bad player to move", "7/7/7/7/7/7 3 classic 4 0-0"},
+		{"floating disc", "7/7/7/7/3x3/7 2 classic 4 0-0"},
+		{"unknown cell", "7/7/7/7/7/3z3 2 classic 4 0-0"},
+		{"wrong player to move", "7/7/7/7/7/3x3 1 classic 4 0-0"},
+		{"kept discs outside pop ten", "7/7/7/7/7/7 1 classic 4 1-0"},
+		{"too many kept discs", "7/7/7/7/7/7 1 popten 4 10-0"},
+		{"connect not a number", "7/7/7/7/7/7 1 classic four 0-0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePosition(tt.notation); !errors.Is(err, ErrInvalidNotation) {
+				t.Errorf("error = %v, want ErrInvalidNotation", err)
+			}
+		})
+	}
+}
+
+func TestReplayMoves(t *testing.T) {
+	moves, err := ParseMoves("4453")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, played, err := ReplayMoves(VariantClassic, Dimensions{}, moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "7/7/7/7/3o3/2oxx2 1 classic 4 0-0"; pos.String() != want {
+		t.Errorf("position = %q, want %q", pos.String(), want)
+	}
+	for i, move := range played {
+		if move.PlayerNum != i%2+1 {
+			t.Errorf("move %d played by %d", i+1, move.PlayerNum)
+		}
+	}
+
+	// Moves after the game is over are refused
+	moves, _ = ParseMoves("11223344")
+	if _, _, err := ReplayMoves(VariantClassic, Dimensions{}, moves); !errors.Is(err, ErrInvalidNotation) {
+		t.Errorf("error = %v, want ErrInvalidNotation", err)
+	}
+}
@@ -7,38 +7,90 @@ import (
 	"time"
 
 	"4_rows_backend/internal/storage"
+
+	"github.com/google/uuid"
 )
 
 type GameState struct {
-	Board       [Rows][Cols]int
-	CurrentTurn int
-	GameOver    bool
-	Winner      int
+	GameID          string
+	Board           [][]int
+	Dimensions      Dimensions
+	Variant         Variant
+	Kept            [2]int
+	CurrentTurn     int
+	GameStarted     bool
+	GameOver        bool
+	Winner          int
+	Players         [2]PlayerSlot
+	Moves           []Move
+	WinningCells    []CellPos
+	RematchRequests [2]bool
+	EndReason       EndReason
+	DrawOfferedBy   int
+	TakebackBy      int
+	TimeControl     TimeControl
+	Clock           *ClockSnapshot // nil in untimed games
 }
 
 type Room struct {
 	Code            string
+	GameID          string // identifies the current game in the archive; a rematch gets a new one
 	Players         [2]PlayerSlot
 	Board           Board
+	Variant         Variant
 	CurrentTurn     int
 	GameStarted     bool
 	GameOver        bool
 	Winner          int
 	RematchRequests [2]bool
 	IsBotGame       bool
+	BotLevel        string
+	Rated           bool
+	Public          bool // listed in the lobby while waiting for an opponent
+	CreatedAt       time.Time
+	Moves           []Move
+	WinningCells    []CellPos
+	EndReason       EndReason
+	DrawOfferedBy   int    // player with a pending draw offer, 0 if there is none
+	TakebackBy      int    // player with a pending takeback request, 0 if there is none
+	startPosition   string // position notation of a seeded start, empty for the variant's own
+	archived        bool
+	ruleState       RuleState
+	clock           Clock
+	clockTimer      *time.Timer
+	lastAnalysis    time.Time
+	version         int // bumped whenever the position changes, see PlayFrom
 	mu              sync.Mutex
 }
 
-type PlayerSlot struct {
-	ID        string
+// Player is whoever takes a seat in a room
+type Player struct {
+	ID        string // client ID, replaced when the player reconnects
 	Name      string
+	AccountID string // registered account, empty for guests
+	GuestID   string // stable ID of a guest, empty for registered players
+}
+
+// Identity is the ID the player's games are attributed to: the account of a
+// registered player, otherwise the guest ID
+func (p Player) Identity() string {
+	if p.AccountID != "" {
+		return p.AccountID
+	}
+	return p.GuestID
+}
+
+type PlayerSlot struct {
+	Player
 	Connected bool
 }
 
 type RoomManager struct {
-	rooms   map[string]*Room
-	mu      sync.RWMutex
-	storage *storage.SQLiteStorage
+	rooms     map[string]*Room
+	mu        sync.RWMutex
+	storage   *storage.SQLiteStorage
+	onTimeout func(room *Room, loser int)
+	onLobby   func(room LobbyRoom, open bool)
 }
 
 var manager *RoomManager
@@ -48,10 +100,24 @@ func (r *Room) State() GameState {
 	defer r.mu.Unlock()
 
 	return GameState{
-		Board:       r.Board.Grid,
-		CurrentTurn: r.CurrentTurn,
-		GameOver:    r.GameOver,
-		Winner:      r.Winner,
+		GameID:          r.GameID,
+		Board:           r.Board.Grid(),
+		Dimensions:      r.Board.Dimensions(),
+		Variant:         r.Variant,
+		Kept:            r.ruleState.Kept,
+		CurrentTurn:     r.CurrentTurn,
+		GameStarted:     r.GameStarted,
+		GameOver:        r.GameOver,
+		Winner:          r.Winner,
+		Players:         r.Players,
+		Moves:           append([]Move(nil), r.Moves...),
+		WinningCells:    append([]CellPos(nil), r.WinningCells...),
+		RematchRequests: r.RematchRequests,
+		EndReason:       r.EndReason,
+		DrawOfferedBy:   r.DrawOfferedBy,
+		TakebackBy:      r.TakebackBy,
+		TimeControl:     r.clock.Control,
+		Clock:           r.clockSnapshot(time.Now()),
 	}
 }
 
@@ -69,25 +135,58 @@ func (rm *RoomManager) SetStorage(s *storage.SQLiteStorage) {
 	rm.storage = s
 }
 
+// SetTimeoutHandler registers the function called after a player loses on time
+func (rm *RoomManager) SetTimeoutHandler(fn func(room *Room, loser int)) {
+	rm.onTimeout = fn
+}
+
 // saveRoom persists a room to SQLite if storage is configured
 func (rm *RoomManager) saveRoom(room *Room) {
 	if rm.storage == nil {
 		return
 	}
 
+	room.mu.Lock()
+	clock := room.clockSnapshot(time.Now())
 	data := &storage.RoomData{
-		Code:        room.Code,
-		Player1ID:   room.Players[0].ID,
-		Player1Name: room.Players[0].Name,
-		Player2ID:   room.Players[1].ID,
-		Player2Name: room.Players[1].Name,
-		Board:       room.Board.Grid,
-		CurrentTurn: room.CurrentTurn,
-		GameStarted: room.GameStarted,
-		GameOver:    room.GameOver,
-		Winner:      room.Winner,
-		IsBotGame:   room.IsBotGame,
+		Code:           room.Code,
+		GameID:         room.GameID,
+		StartPosition:  room.startPosition,
+		Player1ID:      room.Players[0].ID,
+		Player1Name:    room.Players[0].Name,
+		Player1Account: room.Players[0].AccountID,
+		Player1Guest:   room.Players[0].GuestID,
+		Player2ID:      room.Players[1].ID,
+		Player2Name:    room.Players[1].Name,
+		Player2Account: room.Players[1].AccountID,
+		Player2Guest:   room.Players[1].GuestID,
+		Board:          room.Board.Grid(),
+		Connect:        room.Board.Connect(),
+		Variant:        string(room.Variant),
+		Kept:           room.ruleState.Kept,
+		CurrentTurn:    room.CurrentTurn,
+		GameStarted:    room.GameStarted,
+		GameOver:       room.GameOver,
+		Winner:         room.Winner,
+		IsBotGame:      room.IsBotGame,
+		BotLevel:       room.BotLevel,
+		Rated:          room.Rated,
+		Public:         room.Public,
+		CreatedAt:      room.CreatedAt,
+		TimeControl: storage.TimeControlData{
+			Initial:   room.clock.Control.Initial,
+			Increment: room.clock.Control.Increment,
+			PerMove:   room.clock.Control.PerMove,
+		},
 	}
+	if clock != nil {
+		data.Clock = clock.Remaining
+	}
+	data.Moves = make([]storage.MoveData, len(room.Moves))
+	for i, move := range room.Moves {
+		data.Moves[i] = moveData(i+1, move)
+	}
+	room.mu.Unlock()
 
 	if err := rm.storage.SaveRoom(data); err != nil {
 		log.Printf("Error saving room %s to SQLite: %v", room.Code, err)
@@ -96,6 +195,184 @@ func (rm *RoomManager) saveRoom(room *Room) {
 	}
 }
 
+// RestoreRooms loads every unfinished room from SQLite into memory.
+// Human players come back disconnected until they resume from a new connection.
+func (rm *RoomManager) RestoreRooms() ([]*Room, error) {
+	if rm.storage == nil {
+		return nil, nil
+	}
+
+	codes, err := rm.storage.GetUnfinishedRoomCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	var restored []*Room
+	for _, code := range codes {
+		data, err := rm.storage.GetRoom(code)
+		if err != nil {
+			log.Printf("Error loading room %s from SQLite: %v", code, err)
+			continue
+		}
+		if data == nil || rm.rooms[code] != nil {
+			continue
+		}
+
+		board, err := BoardFromGrid(data.Board, data.Connect)
+		if err != nil {
+			log.Printf("Error restoring board of room %s: %v", code, err)
+			continue
+		}
+
+		room := &Room{
+			Code:        data.Code,
+			GameID:      data.GameID,
+			Board:       board,
+			Variant:     RulesFor(Variant(data.Variant)).Variant(),
+			ruleState:   NewRuleState(),
+			CurrentTurn: data.CurrentTurn,
+			GameStarted: data.GameStarted,
+			GameOver:    data.GameOver,
+			Winner:      data.Winner,
+			IsBotGame:   data.IsBotGame,
+			BotLevel:    data.BotLevel,
+			Rated:       data.Rated,
+			Public:      data.Public,
+			CreatedAt:   data.CreatedAt,
+
+			startPosition: data.StartPosition,
+		}
+		room.ruleState.Kept = data.Kept
+		if room.GameID == "" {
+			room.GameID = uuid.NewString()
+		}
+		room.clock = NewClock(TimeControl{
+			Initial:   data.TimeControl.Initial,
+			Increment: data.TimeControl.Increment,
+			PerMove:   data.TimeControl.PerMove,
+		})
+		if room.clock.Control.Enabled() && data.Clock != [2]time.Duration{} {
+			room.clock.remaining = data.Clock
+		}
+		room.Players[0] = PlayerSlot{Player: Player{ID: data.Player1ID, Name: data.Player1Name, AccountID: data.Player1Account, GuestID: data.Player1Guest}}
+		room.Players[1] = PlayerSlot{Player: Player{ID: data.Player2ID, Name: data.Player2Name, AccountID: data.Player2Account, GuestID: data.Player2Guest}}
+		room.restoreMoves(data.Moves)
+		if room.IsBotGame {
+			room.Players[1].Connected = true
+		}
+
+		// The clock of the player to move runs again from the restart; time spent
+		// while the server was down is not charged
+		if room.GameStarted && !room.GameOver {
+			room.startClock()
+		}
+
+		rm.rooms[code] = room
+		restored = append(restored, room)
+	}
+
+	return restored, nil
+}
+
+// restoreMoves loads the move history of a restored room. When replaying it
+// reproduces the stored board, the replay also rebuilds the repetition history.
+func (r *Room) restoreMoves(moves []storage.MoveData) {
+	for _, m := range moves {
+		r.Moves = append(r.Moves, moveFromData(m))
+	}
+
+	rules := r.Rules()
+	start := r.startingPosition()
+	board, state := start.Board, start.RuleState()
+	for _, move := range r.Moves {
+		if _, err := PlayMove(rules, &board, &state, move); err != nil {
+			log.Printf("Room %s: stored moves do not replay, keeping the stored board", r.Code)
+			return
+		}
+	}
+	if board.Hash() != r.Board.Hash() || state.Kept != r.ruleState.Kept {
+		log.Printf("Room %s: stored moves do not match the board, keeping the stored board", r.Code)
+		return
+	}
+	r.ruleState = state
+}
+
+// startingPosition returns the position the room's games start from. Callers hold r.mu.
+func (r *Room) startingPosition() Position {
+	if r.startPosition != "" {
+		if pos, err := ParsePosition(r.startPosition); err == nil {
+			return pos
+		}
+		log.Printf("Room %s: invalid start position %q, using the variant's", r.Code, r.startPosition)
+	}
+	return Position{Variant: r.Variant, Board: r.Rules().NewBoard(r.Board.Dimensions()), ToMove: 1}
+}
+
+// Position returns the current position of the room's game
+func (r *Room) Position() Position {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Position{Variant: r.Variant, Board: r.Board, ToMove: r.CurrentTurn, Kept: r.ruleState.Kept}
+}
+
+// StartPosition returns the seeded position the game started from in
+// position notation, or "" if it started from the variant's position
+func (r *Room) StartPosition() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.startPosition
+}
+
+// GetMoves returns the moves played so far in a room, from memory while the
+// room is open and from SQLite otherwise
+func (rm *RoomManager) GetMoves(code string) ([]Move, error) {
+	if room := rm.GetRoom(code); room != nil {
+		return room.State().Moves, nil
+	}
+	if rm.storage == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	stored, err := rm.storage.GetMoves(code)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, ErrRoomNotFound
+	}
+	moves := make([]Move, len(stored))
+	for i, m := range stored {
+		moves[i] = moveFromData(m)
+	}
+	return moves, nil
+}
+
+func moveFromData(m storage.MoveData) Move {
+	return Move{
+		Kind:      MoveKind(m.Action),
+		Column:    m.Column,
+		Row:       m.Row,
+		PlayerNum: m.PlayerNum,
+		Kept:      m.Kept,
+		PlayedAt:  m.PlayedAt,
+	}
+}
+
+func moveData(ply int, move Move) storage.MoveData {
+	return storage.MoveData{
+		Ply:       ply,
+		PlayerNum: move.PlayerNum,
+		Action:    string(move.Kind),
+		Column:    move.Column,
+		Row:       move.Row,
+		Kept:      move.Kept,
+		PlayedAt:  move.PlayedAt,
+	}
+}
+
 // updateActivity updates the last activity timestamp in SQLite
 func (rm *RoomManager) updateActivity(code string) {
 	if rm.storage != nil {
@@ -107,9 +384,63 @@ func (rm *RoomManager) updateActivity(code string) {
 func (rm *RoomManager) SaveRoomState(room *Room) {
 	rm.saveRoom(room)
 	rm.updateActivity(room.Code)
+	rm.archiveGame(room)
+}
+
+// RoomOptions are the settings chosen by the player who creates a room
+type RoomOptions struct {
+	Dimensions  Dimensions // zero value selects the classic board
+	Variant     Variant    // empty selects DefaultVariant
+	Rated       bool
+	Public      bool // list the room in the lobby
+	BotLevel    string
+	BotFirst    bool        // in bot games, the bot (player 2) makes the first move
+	TimeControl TimeControl // zero value for an untimed game
+
+	// Start seeds the room with a position instead of the variant's starting
+	// one; its variant and board size replace Variant and Dimensions
+	Start *Position
+}
+
+// start validates the options and sets up the position the game starts from
+func (o RoomOptions) start() (Position, error) {
+	if err := o.TimeControl.Validate(); err != nil {
+		return Position{}, err
+	}
+	if o.Start != nil {
+		return *o.Start, nil
+	}
+
+	variant, err := ParseVariant(string(o.Variant))
+	if err != nil {
+		return Position{}, err
+	}
+	rules := RulesFor(variant)
+	d, err := rules.Dimensions(o.Dimensions)
+	if err != nil {
+		return Position{}, err
+	}
+	start := Position{Variant: variant, Board: rules.NewBoard(d), ToMove: 1}
+	if o.BotFirst {
+		start.ToMove = 2
+	}
+	return start, nil
+}
+
+// startNotation is how a room remembers a starting position other than the variant's own
+func (o RoomOptions) startNotation(start Position) string {
+	if o.Start == nil && start.ToMove == 1 {
+		return ""
+	}
+	return start.String()
 }
 
-func (rm *RoomManager) CreateRoom(playerID string, playerName string) *Room {
+func (rm *RoomManager) CreateRoom(player Player, opts RoomOptions) (*Room, error) {
+	start, err := opts.start()
+	if err != nil {
+		return nil, err
+	}
+
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
@@ -120,17 +451,34 @@ func (rm *RoomManager) CreateRoom(playerID string, playerName string) *Room {
 	}
 
 	room := &Room{
-		Code:        code,
-		CurrentTurn: 1,
+		Code:          code,
+		GameID:        uuid.NewString(),
+		Board:         start.Board,
+		Variant:       start.Variant,
+		ruleState:     start.RuleState(),
+		startPosition: opts.startNotation(start),
+		clock:         NewClock(opts.TimeControl),
+		CurrentTurn:   start.ToMove,
+		Rated:         opts.Rated,
+		Public:        opts.Public,
+		CreatedAt:     time.Now(),
 	}
-	room.Players[0] = PlayerSlot{ID: playerID, Name: playerName, Connected: true}
+	room.Players[0] = PlayerSlot{Player: player, Connected: true}
 
 	rm.rooms[code] = room
 	rm.saveRoom(room)
-	return room
+	if room.Public {
+		rm.notifyLobby(room.lobbyRoom(), true)
+	}
+	return room, nil
 }
 
-func (rm *RoomManager) CreateBotRoom(playerID string, playerName string) *Room {
+func (rm *RoomManager) CreateBotRoom(player Player, opts RoomOptions) (*Room, error) {
+	start, err := opts.start()
+	if err != nil {
+		return nil, err
+	}
+
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
@@ -141,20 +489,29 @@ func (rm *RoomManager) CreateBotRoom(playerID string, playerName string) *Room {
 	}
 
 	room := &Room{
-		Code:        code,
-		CurrentTurn: 1,
-		IsBotGame:   true,
-		GameStarted: true, // Bot game starts immediately
+		Code:          code,
+		GameID:        uuid.NewString(),
+		Board:         start.Board,
+		Variant:       start.Variant,
+		ruleState:     start.RuleState(),
+		startPosition: opts.startNotation(start),
+		clock:         NewClock(opts.TimeControl),
+		CurrentTurn:   start.ToMove,
+		IsBotGame:     true,
+		BotLevel:      opts.BotLevel,
+		GameStarted:   true, // Bot game starts immediately
+		CreatedAt:     time.Now(),
 	}
-	room.Players[0] = PlayerSlot{ID: playerID, Name: playerName, Connected: true}
-	room.Players[1] = PlayerSlot{ID: "bot", Name: "Bot", Connected: true}
+	room.Players[0] = PlayerSlot{Player: player, Connected: true}
+	room.Players[1] = PlayerSlot{Player: Player{ID: "bot", Name: "Bot"}, Connected: true}
+	room.startClock()
 
 	rm.rooms[code] = room
 	rm.saveRoom(room)
-	return room
+	return room, nil
 }
 
-func (rm *RoomManager) JoinRoom(code string, playerID string, playerName string) (*Room, error) {
+func (rm *RoomManager) JoinRoom(code string, player Player) (*Room, error) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
@@ -163,14 +520,21 @@ func (rm *RoomManager) JoinRoom(code string, playerID string, playerName string)
 		return nil, ErrRoomNotFound
 	}
 
-	if room.Players[1].Connected {
+	if room.Players[1].ID != "" {
 		return nil, ErrRoomFull
 	}
 
-	room.Players[1] = PlayerSlot{ID: playerID, Name: playerName, Connected: true}
+	room.mu.Lock()
+	wasOpen := room.open()
+	room.Players[1] = PlayerSlot{Player: player, Connected: true}
 	room.GameStarted = true
+	room.startClock()
+	room.mu.Unlock()
 
 	rm.saveRoom(room)
+	if wasOpen {
+		rm.notifyLobby(LobbyRoom{Code: code}, false)
+	}
 	return room, nil
 }
 
@@ -183,13 +547,69 @@ func (rm *RoomManager) GetRoom(code string) *Room {
 func (rm *RoomManager) RemoveRoom(code string) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
+	wasOpen := false
+	if room := rm.rooms[code]; room != nil {
+		room.mu.Lock()
+		room.stopClock()
+		wasOpen = room.open()
+		room.mu.Unlock()
+	}
 	delete(rm.rooms, code)
 	if rm.storage != nil {
 		rm.storage.DeleteRoom(code)
 	}
+	if wasOpen {
+		rm.notifyLobby(LobbyRoom{Code: code}, false)
+	}
+}
+
+// DisconnectPlayer marks a player's slot as disconnected while it is held for
+// reconnection. A public room leaves the lobby until its host is back.
+func (rm *RoomManager) DisconnectPlayer(room *Room, playerNum int) {
+	room.mu.Lock()
+	wasOpen := room.open()
+	room.Players[playerNum-1].Connected = false
+	room.mu.Unlock()
+
+	if wasOpen {
+		rm.notifyLobby(LobbyRoom{Code: room.Code}, false)
+	}
+}
+
+// ReconnectPlayer rebinds a held player slot to a new client ID
+func (rm *RoomManager) ReconnectPlayer(code string, playerNum int, oldID string, newID string) (*Room, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	room := rm.rooms[code]
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	room.mu.Lock()
+	slot := &room.Players[playerNum-1]
+	if slot.ID != oldID {
+		room.mu.Unlock()
+		return nil, ErrSlotTaken
+	}
+	wasOpen := room.open()
+	slot.ID = newID
+	slot.Connected = true
+	reopened := !wasOpen && room.open()
+	lobbyRoom := room.lobbyRoom()
+	room.mu.Unlock()
+
+	rm.saveRoom(room)
+	if reopened {
+		rm.notifyLobby(lobbyRoom, true)
+	}
+	return room, nil
 }
 
 func (rm *RoomManager) GetPlayerNumber(room *Room, playerID string) int {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
 	if room.Players[0].ID == playerID {
 		return 1
 	}
@@ -198,46 +618,235 @@ func (rm *RoomManager) GetPlayerNumber(room *Room, playerID string) int {
 	}
 	return 0
 }
+
+// CopyPosition returns a snapshot of the board and rule state that can be
+// searched without holding the room lock, and the version of the position
+// for PlayFrom
+func (r *Room) CopyPosition() (Board, RuleState, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Board, r.ruleState.Snapshot(), r.version
+}
+
+// Rules returns the ruleset of the room's variant
+func (r *Room) Rules() Ruleset {
+	return RulesFor(r.Variant)
+}
+
+// Dimensions returns the size of the room's board and its connect length
+func (r *Room) Dimensions() Dimensions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Board.Dimensions()
+}
+
+// AnalysisAllowed reports whether engine hints may be used in this room.
+// Rated games between two people are played without assistance.
+func (r *Room) AnalysisAllowed() bool {
+	return r.IsBotGame || !r.Rated
+}
+
+// TryStartAnalysis rate limits engine analysis per room, returning false
+// if the previous analysis started less than cooldown ago
+func (r *Room) TryStartAnalysis(cooldown time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastAnalysis) < cooldown {
+		return false
+	}
+	r.lastAnalysis = now
+	return true
+}
+
+// Player returns who sits in a player slot; the ID changes when a held slot is resumed
+func (r *Room) Player(playerNum int) Player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Players[playerNum-1].Player
+}
+
+// IsConnected reports whether the given player currently has a live connection
+func (r *Room) IsConnected(playerNum int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Players[playerNum-1].Connected
+}
+
+// MakeMove drops a disc for playerNum and returns the row it landed in
 func (r *Room) MakeMove(column int, playerNum int) (int, error) {
+	result, err := r.Play(Move{Kind: MoveDrop, Column: column, PlayerNum: playerNum})
+	if err != nil {
+		return -1, err
+	}
+	return result.Move.Row, nil
+}
+
+// Play applies a move under the room's rules and reports how the game stands afterwards
+func (r *Room) Play(move Move) (MoveResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.play(move)
+}
+
+// PlayFrom plays a move that was chosen on the position CopyPosition returned
+// with version. If the position has changed since, e.g. by a takeback or a
+// rematch, the move is refused with ErrPositionChanged.
+func (r *Room) PlayFrom(version int, move Move) (MoveResult, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.version != version {
+		return MoveResult{}, ErrPositionChanged
+	}
+	return r.play(move)
+}
+
+// play applies a move. Callers hold r.mu.
+func (r *Room) play(move Move) (MoveResult, error) {
 	if r.GameOver {
-		return -1, ErrInvalidMove
+		return MoveResult{}, ErrInvalidMove
 	}
 
-	if r.CurrentTurn != playerNum {
-		return -1, ErrNotYourTurn
+	if r.CurrentTurn != move.PlayerNum {
+		return MoveResult{}, ErrNotYourTurn
 	}
 
-	row, ok := r.Board.Drop(column, playerNum)
-	if !ok {
-		return -1, ErrInvalidMove
+	// A move that arrives after the flag fell is refused; the timer ends the game
+	now := time.Now()
+	if r.clock.Control.Enabled() && r.clock.Remaining(move.PlayerNum, now) <= 0 {
+		return MoveResult{}, ErrTimeUp
 	}
 
-	if won, _ := r.Board.CheckWin(row, column, playerNum); won {
-		r.GameOver = true
-		r.Winner = playerNum
-	} else if r.Board.IsDraw() {
-		r.GameOver = true
+	result, err := PlayMove(r.Rules(), &r.Board, &r.ruleState, move)
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	result.Move.PlayedAt = now
+	r.Moves = append(r.Moves, result.Move)
+	r.version++
+	r.CurrentTurn = result.NextPlayer
+
+	// Moving instead of answering a draw offer declines it, and a takeback
+	// request no longer refers to the last move
+	if r.DrawOfferedBy == 3-move.PlayerNum {
+		r.DrawOfferedBy = 0
+	}
+	r.TakebackBy = 0
+
+	if result.GameOver {
+		r.finish(result.Winner, result.Reason)
+		r.WinningCells = result.WinningCells
+	} else if r.clock.Control.Enabled() {
+		r.clock.Moved(move.PlayerNum, result.NextPlayer, now)
+		r.armClock()
 	}
 
-	r.CurrentTurn = 3 - playerNum
-	return row, nil
+	result.WinningCells = append([]CellPos(nil), r.WinningCells...)
+	result.Clock = r.clockSnapshot(now)
+	return result, nil
 }
 
 func (r *Room) ResetGame() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Reset the board
-	r.Board = Board{}
+	// Reset the board, to the seeded position if the room has one
+	start := r.startingPosition()
+	r.Board = start.Board
+	r.ruleState = start.RuleState()
+	r.stopClock()
+	r.clock = NewClock(r.clock.Control)
 
 	// Reset game state
-	r.CurrentTurn = 1
+	r.CurrentTurn = start.ToMove
 	r.GameOver = false
 	r.Winner = 0
 	r.RematchRequests = [2]bool{false, false}
+	r.Moves = nil
+	r.version++
+	r.GameID = uuid.NewString()
+	r.archived = false
+	r.WinningCells = nil
+	r.EndReason = ""
+	r.DrawOfferedBy = 0
+	r.TakebackBy = 0
+	r.startClock()
+}
+
+// TimeControl returns the room's time control, the zero value if it is untimed
+func (r *Room) TimeControl() TimeControl {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clock.Control
+}
+
+// startClock runs the clock of the player to move. Callers hold r.mu.
+func (r *Room) startClock() {
+	if !r.clock.Control.Enabled() {
+		return
+	}
+	r.clock.Start(r.CurrentTurn, time.Now())
+	r.armClock()
+}
+
+// stopClock stops the clock and its flag timer. Callers hold r.mu.
+func (r *Room) stopClock() {
+	r.clock.Stop(time.Now())
+	if r.clockTimer != nil {
+		r.clockTimer.Stop()
+		r.clockTimer = nil
+	}
+}
+
+// armClock schedules a flag check for when the running player's time runs out. Callers hold r.mu.
+func (r *Room) armClock() {
+	if r.clockTimer != nil {
+		r.clockTimer.Stop()
+		r.clockTimer = nil
+	}
+	player := r.clock.running
+	if player == 0 {
+		return
+	}
+	left := max(r.clock.Remaining(player, time.Now()), 0)
+	r.clockTimer = time.AfterFunc(left, func() { r.flag(player) })
+}
+
+// clockSnapshot returns the clock at now, or nil in untimed games. Callers hold r.mu.
+func (r *Room) clockSnapshot(now time.Time) *ClockSnapshot {
+	if !r.clock.Control.Enabled() {
+		return nil
+	}
+	snapshot := r.clock.Snapshot(now)
+	return &snapshot
+}
+
+// flag ends the game if player's time has run out and tells the timeout handler
+func (r *Room) flag(player int) {
+	r.mu.Lock()
+	now := time.Now()
+	if r.GameOver || r.clock.running != player {
+		r.mu.Unlock()
+		return
+	}
+	if left := r.clock.Remaining(player, now); left > 0 {
+		// The timer fired early or the clock was restarted; check again later
+		r.clockTimer = time.AfterFunc(left, func() { r.flag(player) })
+		r.mu.Unlock()
+		return
+	}
+
+	r.finish(3-player, EndTimeout)
+	r.mu.Unlock()
+
+	rm := GetRoomManager()
+	rm.SaveRoomState(r)
+	if rm.onTimeout != nil {
+		rm.onTimeout(r, player)
+	}
 }
 
 func (r *Room) RequestRematch(playerNum int) bool {
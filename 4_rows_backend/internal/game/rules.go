@@ -0,0 +1,165 @@
+package game
+
+import "maps"
+
+// Ruleset decides which moves are legal, how they change the board and when
+// the game is over. Implementations hold no per-game data; anything a variant
+// needs to remember besides the board lives in RuleState.
+type Ruleset interface {
+	Variant() Variant
+
+	// Dimensions returns the board to play on given the dimensions a player
+	// asked for, which are the zero value when they did not ask
+	Dimensions(requested Dimensions) (Dimensions, error)
+
+	// NewBoard returns the starting position
+	NewBoard(d Dimensions) Board
+
+	// LegalMoves appends the moves player may make to moves
+	LegalMoves(b *Board, state *RuleState, player int, moves []Move) []Move
+
+	// Apply makes a move, filling in its kind and row, or returns ErrInvalidMove
+	Apply(b *Board, state *RuleState, move Move) (Move, error)
+
+	// Undo takes back the last move made, as returned by Apply
+	Undo(b *Board, state *RuleState, move Move) error
+
+	// Outcome is the terminal check after last was applied
+	Outcome(b *Board, state *RuleState, last Move) Outcome
+}
+
+// Outcome reports whether a game is over and who won it
+type Outcome struct {
+	Over         bool
+	Winner       int // 0 for a draw
+	Reason       EndReason
+	WinningCells []CellPos
+}
+
+// RuleState is what a ruleset remembers besides the board
+type RuleState struct {
+	Kept [2]int // Pop Ten: discs each player has popped out of a line and kept
+
+	// positions counts how often each position occurred, for repetition draws
+	// in variants with pops. Engine searches leave it nil and ignore repetitions.
+	positions map[uint64]int
+}
+
+// NewRuleState returns an empty state that tracks repetitions
+func NewRuleState() RuleState {
+	return RuleState{positions: make(map[uint64]int)}
+}
+
+// Snapshot copies the state without its repetition history
+func (s *RuleState) Snapshot() RuleState {
+	return RuleState{Kept: s.Kept}
+}
+
+// clone copies the state including its repetition history
+func (s *RuleState) clone() RuleState {
+	return RuleState{Kept: s.Kept, positions: maps.Clone(s.positions)}
+}
+
+// Hash tells apart states that share a board, for transposition tables
+func (s *RuleState) Hash() uint64 {
+	if s.Kept == [2]int{} {
+		return 0
+	}
+	return splitMix64(uint64(s.Kept[0]) | uint64(s.Kept[1])<<8 | 1<<16)
+}
+
+func (s *RuleState) recordPosition(b *Board, toMove int) {
+	if s.positions != nil {
+		s.positions[b.PositionKey(toMove)]++
+	}
+}
+
+func (s *RuleState) forgetPosition(b *Board, toMove int) {
+	if key := b.PositionKey(toMove); s.positions != nil && s.positions[key] > 0 {
+		s.positions[key]--
+	}
+}
+
+func (s *RuleState) repeated(b *Board, toMove int) bool {
+	return s.positions != nil && s.positions[b.PositionKey(toMove)] >= repetitionLimit
+}
+
+// PlayMove applies a move under rules and works out who moves next. A player
+// without a legal move passes; if neither player can move the game is drawn.
+func PlayMove(rules Ruleset, b *Board, state *RuleState, move Move) (MoveResult, error) {
+	move, err := rules.Apply(b, state, move)
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	result := MoveResult{Move: move, NextPlayer: 3 - move.PlayerNum}
+	outcome := rules.Outcome(b, state, move)
+	if !outcome.Over && !hasLegalMove(rules, b, state, result.NextPlayer) {
+		if hasLegalMove(rules, b, state, move.PlayerNum) {
+			result.NextPlayer = move.PlayerNum
+		} else {
+			outcome.Over = true
+			outcome.Reason = EndBoardFull
+		}
+	}
+
+	result.GameOver = outcome.Over
+	result.Winner = outcome.Winner
+	result.Reason = outcome.Reason
+	result.WinningCells = outcome.WinningCells
+	return result, nil
+}
+
+func hasLegalMove(rules Ruleset, b *Board, state *RuleState, player int) bool {
+	return len(rules.LegalMoves(b, state, player, nil)) > 0
+}
+
+// requestedOrClassic validates requested dimensions, defaulting to the classic board
+func requestedOrClassic(requested Dimensions) (Dimensions, error) {
+	if requested == (Dimensions{}) {
+		return ClassicDimensions, nil
+	}
+	return requested, requested.Validate()
+}
+
+// appendDrops appends a drop for every column that is not full
+func appendDrops(b *Board, player int, moves []Move) []Move {
+	for col := 0; col < b.Cols(); col++ {
+		if b.CanPlay(col) {
+			moves = append(moves, Move{Kind: MoveDrop, Column: col, PlayerNum: player})
+		}
+	}
+	return moves
+}
+
+// appendPops appends a pop for every column whose bottom disc belongs to player
+func appendPops(b *Board, player int, moves []Move) []Move {
+	for col := 0; col < b.Cols(); col++ {
+		if b.CanPop(col, player) {
+			moves = append(moves, Move{Kind: MovePop, Column: col, PlayerNum: player})
+		}
+	}
+	return moves
+}
+
+// undoDrop removes a dropped disc from the top of its column
+func undoDrop(b *Board, move Move) error {
+	if b.Height(move.Column) == 0 || b.Cell(b.Rows()-b.Height(move.Column), move.Column) != move.PlayerNum {
+		return ErrInvalidMove
+	}
+	b.Undo(move.Column)
+	return nil
+}
+
+// applyDrop drops a disc, accepting moves without a kind as drops
+func applyDrop(b *Board, move Move) (Move, error) {
+	if move.Kind != MoveDrop && move.Kind != "" {
+		return move, ErrInvalidMove
+	}
+	row, ok := b.Drop(move.Column, move.PlayerNum)
+	if !ok {
+		return move, ErrInvalidMove
+	}
+	move.Kind, move.Row = MoveDrop, row
+	return move, nil
+}
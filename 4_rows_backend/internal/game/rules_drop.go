@@ -0,0 +1,67 @@
+package game
+
+import "fmt"
+
+// FiveInARowDimensions is the nine wide board Five-in-a-Row is played on
+var FiveInARowDimensions = Dimensions{Rows: 6, Cols: 9, Connect: 5}
+
+// dropRules covers the variants where discs are only ever dropped: classic,
+// Five-in-a-Row and anti-connect
+type dropRules struct {
+	variant Variant
+	fixed   Dimensions // the only board the variant is played on, if any
+	prefill bool       // start with the outer columns filled with alternating discs
+	misere  bool       // completing a line loses instead of winning
+}
+
+func (r dropRules) Variant() Variant {
+	return r.variant
+}
+
+func (r dropRules) Dimensions(requested Dimensions) (Dimensions, error) {
+	if r.fixed == (Dimensions{}) {
+		return requestedOrClassic(requested)
+	}
+	if requested != (Dimensions{}) && requested != r.fixed {
+		return Dimensions{}, fmt.Errorf("%w: %s is only played %dx%d with %d in a row",
+			ErrInvalidDimensions, r.variant, r.fixed.Cols, r.fixed.Rows, r.fixed.Connect)
+	}
+	return r.fixed, nil
+}
+
+func (r dropRules) NewBoard(d Dimensions) Board {
+	b := NewBoard(d)
+	if r.prefill {
+		for i := 0; i < d.Rows; i++ {
+			b.Drop(0, i%2+1)
+			b.Drop(d.Cols-1, 2-i%2)
+		}
+	}
+	return b
+}
+
+func (r dropRules) LegalMoves(b *Board, state *RuleState, player int, moves []Move) []Move {
+	return appendDrops(b, player, moves)
+}
+
+func (r dropRules) Apply(b *Board, state *RuleState, move Move) (Move, error) {
+	return applyDrop(b, move)
+}
+
+func (r dropRules) Undo(b *Board, state *RuleState, move Move) error {
+	return undoDrop(b, move)
+}
+
+func (r dropRules) Outcome(b *Board, state *RuleState, last Move) Outcome {
+	if won, cells := b.CheckWin(last.Row, last.Column, last.PlayerNum); won {
+		winner := last.PlayerNum
+		if r.misere {
+			winner = 3 - winner
+		}
+		return Outcome{Over: true, Winner: winner, Reason: EndConnect, WinningCells: cells}
+	}
+	if b.IsDraw() {
+		return Outcome{Over: true, Reason: EndBoardFull}
+	}
+	return Outcome{}
+}
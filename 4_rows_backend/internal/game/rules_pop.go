@@ -0,0 +1,154 @@
+package game
+
+// popTenTarget is the number of kept discs that wins a game of Pop Ten
+const popTenTarget = 10
+
+// popOutRules let players either drop a disc or pop one of their own from the
+// bottom row. A pop can complete lines for both players at once, in which case
+// the player who popped wins. A full board is not a draw since discs can still
+// be popped, but a position that occurs three times is.
+type popOutRules struct{}
+
+func (popOutRules) Variant() Variant {
+	return VariantPopOut
+}
+
+func (popOutRules) Dimensions(requested Dimensions) (Dimensions, error) {
+	return requestedOrClassic(requested)
+}
+
+func (popOutRules) NewBoard(d Dimensions) Board {
+	return NewBoard(d)
+}
+
+func (popOutRules) LegalMoves(b *Board, state *RuleState, player int, moves []Move) []Move {
+	return appendPops(b, player, appendDrops(b, player, moves))
+}
+
+func (popOutRules) Apply(b *Board, state *RuleState, move Move) (Move, error) {
+	if move.Kind == MovePop {
+		if !b.CanPop(move.Column, move.PlayerNum) {
+			return move, ErrInvalidMove
+		}
+		b.Pop(move.Column)
+		move.Row = b.Rows() - 1
+	} else {
+		var err error
+		if move, err = applyDrop(b, move); err != nil {
+			return move, err
+		}
+	}
+
+	state.recordPosition(b, 3-move.PlayerNum)
+	return move, nil
+}
+
+func (popOutRules) Undo(b *Board, state *RuleState, move Move) error {
+	state.forgetPosition(b, 3-move.PlayerNum)
+	if move.Kind != MovePop {
+		return undoDrop(b, move)
+	}
+	if !b.Unpop(move.Column, move.PlayerNum) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (popOutRules) Outcome(b *Board, state *RuleState, last Move) Outcome {
+	if cells := b.WinningCells(last.PlayerNum); cells != nil {
+		return Outcome{Over: true, Winner: last.PlayerNum, Reason: EndConnect, WinningCells: cells}
+	}
+	if last.Kind == MovePop {
+		if cells := b.WinningCells(3 - last.PlayerNum); cells != nil {
+			return Outcome{Over: true, Winner: 3 - last.PlayerNum, Reason: EndConnect, WinningCells: cells}
+		}
+	}
+	if state.repeated(b, 3-last.PlayerNum) {
+		return Outcome{Over: true, Reason: EndRepetition}
+	}
+	return Outcome{}
+}
+
+// popTenRules start with the players filling the board by dropping discs. Once it
+// is full they take turns popping their own discs from the bottom row. A popped
+// disc that was part of a line is kept; any other popped disc goes back on top of
+// the column it came from (the physical game lets it go on top of any column with
+// room). The first player to keep ten discs wins, and a repeated position is a draw.
+type popTenRules struct{}
+
+func (popTenRules) Variant() Variant {
+	return VariantPopTen
+}
+
+func (popTenRules) Dimensions(requested Dimensions) (Dimensions, error) {
+	return requestedOrClassic(requested)
+}
+
+func (popTenRules) NewBoard(d Dimensions) Board {
+	return NewBoard(d)
+}
+
+// filling reports whether the players are still dropping discs to fill the board.
+// Only kept discs ever leave the board, so it has been full once if any were kept.
+func (popTenRules) filling(b *Board, state *RuleState) bool {
+	return state.Kept == [2]int{} && !b.IsDraw()
+}
+
+func (r popTenRules) LegalMoves(b *Board, state *RuleState, player int, moves []Move) []Move {
+	if r.filling(b, state) {
+		return appendDrops(b, player, moves)
+	}
+	return appendPops(b, player, moves)
+}
+
+func (r popTenRules) Apply(b *Board, state *RuleState, move Move) (Move, error) {
+	if r.filling(b, state) {
+		return applyDrop(b, move)
+	}
+
+	if move.Kind != MovePop || !b.CanPop(move.Column, move.PlayerNum) {
+		return move, ErrInvalidMove
+	}
+
+	bottom := b.Rows() - 1
+	scored, _ := b.CheckWin(bottom, move.Column, move.PlayerNum)
+	b.Pop(move.Column)
+	if scored {
+		state.Kept[move.PlayerNum-1]++
+		move.Kept = true
+	} else {
+		b.Drop(move.Column, move.PlayerNum)
+	}
+	move.Row = bottom
+
+	state.recordPosition(b, 3-move.PlayerNum)
+	return move, nil
+}
+
+func (popTenRules) Undo(b *Board, state *RuleState, move Move) error {
+	if move.Kind != MovePop {
+		return undoDrop(b, move)
+	}
+
+	state.forgetPosition(b, 3-move.PlayerNum)
+	if move.Kept {
+		state.Kept[move.PlayerNum-1]--
+	} else if err := undoDrop(b, move); err != nil {
+		// the disc went back on top of the column
+		return err
+	}
+	if !b.Unpop(move.Column, move.PlayerNum) {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+func (popTenRules) Outcome(b *Board, state *RuleState, last Move) Outcome {
+	if state.Kept[last.PlayerNum-1] >= popTenTarget {
+		return Outcome{Over: true, Winner: last.PlayerNum, Reason: EndConnect}
+	}
+	if last.Kind == MovePop && state.repeated(b, 3-last.PlayerNum) {
+		return Outcome{Over: true, Reason: EndRepetition}
+	}
+	return Outcome{}
+}
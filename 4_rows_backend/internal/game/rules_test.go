@@ -0,0 +1,176 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// playSequence plays a move sequence from the starting position of a variant
+// and returns the position and the result of the last move
+func playSequence(t *testing.T, variant Variant, sequence string) (Position, RuleState, MoveResult) {
+	t.Helper()
+	moves, err := ParseMoves(sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := RulesFor(variant)
+	d, err := rules.Dimensions(Dimensions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := Position{Variant: variant, Board: rules.NewBoard(d), ToMove: 1}
+	state := NewRuleState()
+	var result MoveResult
+	for i, move := range moves {
+		if result.GameOver {
+			t.Fatalf("game over before move %d", i+1)
+		}
+		move.PlayerNum = pos.ToMove
+		if result, err = PlayMove(rules, &pos.Board, &state, move); err != nil {
+			t.Fatalf("move %d (%s): %v", i+1, FormatMoves([]Move{move}), err)
+		}
+		pos.ToMove = result.NextPlayer
+	}
+	return pos, state, result
+}
+
+func TestRulesOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		variant  Variant
+		sequence string
+		over     bool
+		winner   int
+		reason   EndReason
+	}{
+		{"classic line wins", VariantClassic, "1122334", true, 1, EndConnect},
+		{"classic three is not enough", VariantClassic, "112233", false, 0, ""},
+		{"classic full board draws", VariantClassic, "434237644576543313354222261665167571521717", true, 0, EndBoardFull},
+		{"anti-connect line loses", VariantAntiConnect, "1122334", true, 2, EndConnect},
+		{"anti-connect vertical loses", VariantAntiConnect, "1213141", true, 2, EndConnect},
+		{"five in a row counts the prefilled column", VariantFiveInARow, "2838485", true, 1, EndConnect},
+		{"five in a row ignores four", VariantFiveInARow, "28384", false, 0, ""},
+		{"popout drop line wins", VariantPopOut, "1213141", true, 1, EndConnect},
+		{"popten ignores lines while filling", VariantPopTen, "1122334", false, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, result := playSequence(t, tt.variant, tt.sequence)
+			if result.GameOver != tt.over || result.Winner != tt.winner || result.Reason != tt.reason {
+				t.Errorf("got over=%v winner=%d reason=%q, want over=%v winner=%d reason=%q",
+					result.GameOver, result.Winner, result.Reason, tt.over, tt.winner, tt.reason)
+			}
+		})
+	}
+}
+
+func TestPopOutPopCompletesOpponentLine(t *testing.T) {
+	// Popping player 1's disc from column 1 drops player 2's disc into a bottom row line
+	pos, err := ParsePosition("7/7/7/7/o4x1/xooo1xx 1 popout 4 0-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := pos.RuleState()
+	result, err := PlayMove(RulesFor(VariantPopOut), &pos.Board, &state, Move{Kind: MovePop, Column: 0, PlayerNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.GameOver || result.Winner != 2 {
+		t.Errorf("got over=%v winner=%d, want player 2 to win", result.GameOver, result.Winner)
+	}
+}
+
+func TestPopOutRepetitionDraws(t *testing.T) {
+	// Both players keep dropping and popping the same disc, until the position
+	// after the first drop occurs for the third time
+	_, _, result := playSequence(t, VariantPopOut, strings.Repeat("12p1p2", repetitionLimit-1)+"1")
+	if !result.GameOver || result.Reason != EndRepetition {
+		t.Errorf("got over=%v reason=%q, want a repetition draw", result.GameOver, result.Reason)
+	}
+}
+
+func TestPopTen(t *testing.T) {
+	// Player 1 fills the bottom row from column 1 to 4, then the rest of the board is filled
+	fill := "1122334" + strings.Repeat("1", 4) + strings.Repeat("2", 4) + strings.Repeat("3", 4) +
+		strings.Repeat("4", 5) + strings.Repeat("5", 6) + strings.Repeat("6", 6) + strings.Repeat("7", 6)
+	pos, state, result := playSequence(t, VariantPopTen, fill)
+	if result.GameOver {
+		t.Fatal("filling the board should not end the game")
+	}
+	if pos.ToMove != 1 {
+		t.Fatalf("player %d to move after filling, want 1", pos.ToMove)
+	}
+
+	rules := RulesFor(VariantPopTen)
+	for _, move := range rules.LegalMoves(&pos.Board, &state, 1, nil) {
+		if move.Kind != MovePop {
+			t.Fatalf("only pops are legal on a full board, got %s", move.Kind)
+		}
+	}
+
+	// A disc popped out of a line is kept
+	board, kept := pos.Board, state.clone()
+	result, err := PlayMove(rules, &board, &kept, Move{Kind: MovePop, Column: 0, PlayerNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Move.Kept || kept.Kept != [2]int{1, 0} {
+		t.Errorf("got kept=%v, counts %v; want the disc kept", result.Move.Kept, kept.Kept)
+	}
+
+	// Keeping the tenth disc wins
+	board, tenth := pos.Board, state.clone()
+	tenth.Kept = [2]int{popTenTarget - 1, 0}
+	result, err = PlayMove(rules, &board, &tenth, Move{Kind: MovePop, Column: 0, PlayerNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.GameOver || result.Winner != 1 {
+		t.Errorf("got over=%v winner=%d, want player 1 to win", result.GameOver, result.Winner)
+	}
+}
+
+func TestRulesUndo(t *testing.T) {
+	tests := []struct {
+		variant  Variant
+		sequence string
+	}{
+		{VariantClassic, "4453"},
+		{VariantFiveInARow, "5566"},
+		{VariantPopOut, "1212p1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.variant), func(t *testing.T) {
+			rules := RulesFor(tt.variant)
+			d, _ := rules.Dimensions(Dimensions{})
+			start := rules.NewBoard(d)
+
+			moves, err := ParseMoves(tt.sequence)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, played, err := ReplayMoves(tt.variant, Dimensions{}, moves)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			board, state := start, NewRuleState()
+			for _, move := range played {
+				if _, err := rules.Apply(&board, &state, move); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := len(played) - 1; i >= 0; i-- {
+				if err := rules.Undo(&board, &state, played[i]); err != nil {
+					t.Fatalf("undo of move %d: %v", i+1, err)
+				}
+			}
+			if board != start {
+				t.Error("undoing every move did not restore the starting board")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+import "time"
+
+// TakebackAllowed reports whether moves may be taken back in this room.
+// Rated games between two people count every move as played.
+func (r *Room) TakebackAllowed() bool {
+	return r.IsBotGame || !r.Rated
+}
+
+// RequestTakeback asks to take back playerNum's last move, along with any
+// reply the opponent made to it. The bot always agrees, so in bot games the
+// moves are taken back at once and returned; otherwise the request waits for
+// AcceptTakeback and nil is returned.
+func (r *Room) RequestTakeback(playerNum int) ([]Move, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.TakebackAllowed() {
+		return nil, ErrTakebackNotAllowed
+	}
+	if !r.active() {
+		return nil, ErrGameNotActive
+	}
+	if r.lastMoveBy(playerNum) < 0 {
+		return nil, ErrNothingToTakeBack
+	}
+
+	if r.IsBotGame {
+		return r.takeBack(playerNum)
+	}
+	if r.TakebackBy == playerNum {
+		return nil, ErrTakebackRequested
+	}
+	r.TakebackBy = playerNum
+	return nil, nil
+}
+
+// AcceptTakeback grants the opponent's takeback request and returns the moves
+// taken back, most recent first
+func (r *Room) AcceptTakeback(playerNum int) ([]Move, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.active() {
+		return nil, ErrGameNotActive
+	}
+	if r.TakebackBy != 3-playerNum {
+		return nil, ErrNoTakebackRequest
+	}
+	return r.takeBack(3 - playerNum)
+}
+
+// DeclineTakeback turns down the opponent's takeback request
+func (r *Room) DeclineTakeback(playerNum int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.TakebackBy != 3-playerNum {
+		return ErrNoTakebackRequest
+	}
+	r.TakebackBy = 0
+	return nil
+}
+
+// lastMoveBy returns the index in the history of player's last move, or -1. Callers hold r.mu.
+func (r *Room) lastMoveBy(player int) int {
+	for i := len(r.Moves) - 1; i >= 0; i-- {
+		if r.Moves[i].PlayerNum == player {
+			return i
+		}
+	}
+	return -1
+}
+
+// takeBack undoes the moves back to and including player's last one, giving
+// them the turn again with their clock running. Callers hold r.mu.
+func (r *Room) takeBack(player int) ([]Move, error) {
+	from := r.lastMoveBy(player)
+	if from < 0 {
+		return nil, ErrNothingToTakeBack
+	}
+
+	// Undo a copy so a history that does not match the board leaves the room untouched
+	rules := r.Rules()
+	board, state := r.Board, r.ruleState.clone()
+	undone := make([]Move, 0, len(r.Moves)-from)
+	for i := len(r.Moves) - 1; i >= from; i-- {
+		if err := rules.Undo(&board, &state, r.Moves[i]); err != nil {
+			return nil, err
+		}
+		undone = append(undone, r.Moves[i])
+	}
+
+	r.Board, r.ruleState = board, state
+	r.Moves = r.Moves[:from]
+	r.version++
+	r.CurrentTurn = player
+	r.TakebackBy = 0
+	if r.clock.Control.Enabled() {
+		r.clock.Stop(time.Now())
+		r.startClock()
+	}
+	return undone, nil
+}
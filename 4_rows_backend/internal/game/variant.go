@@ -0,0 +1,49 @@
+package game
+
+import "fmt"
+
+// Variant names the rules a room is played with
+type Variant string
+
+const (
+	VariantClassic     Variant = "classic"
+	VariantPopOut      Variant = "popout"        // players may also pop their own discs from the bottom row
+	VariantPopTen      Variant = "popten"        // fill the board, then pop discs out of lines to keep them
+	VariantFiveInARow  Variant = "five_in_a_row" // nine wide board with pre-filled outer columns, five to win
+	VariantAntiConnect Variant = "anticonnect"   // whoever completes a line loses
+
+	DefaultVariant = VariantClassic
+
+	// repetitionLimit is how often the same position may occur before a game with pops is drawn
+	repetitionLimit = 3
+)
+
+var rulesets = map[Variant]Ruleset{
+	VariantClassic:     dropRules{variant: VariantClassic},
+	VariantPopOut:      popOutRules{},
+	VariantPopTen:      popTenRules{},
+	VariantFiveInARow:  dropRules{variant: VariantFiveInARow, fixed: FiveInARowDimensions, prefill: true},
+	VariantAntiConnect: dropRules{variant: VariantAntiConnect, misere: true},
+}
+
+// ParseVariant converts a client supplied name into a Variant.
+// An empty name selects DefaultVariant.
+func ParseVariant(name string) (Variant, error) {
+	if name == "" {
+		return DefaultVariant, nil
+	}
+
+	v := Variant(name)
+	if _, ok := rulesets[v]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownVariant, name)
+	}
+	return v, nil
+}
+
+// RulesFor returns the ruleset of a variant, falling back to the default for unknown names
+func RulesFor(v Variant) Ruleset {
+	if rules, ok := rulesets[v]; ok {
+		return rules
+	}
+	return rulesets[DefaultVariant]
+}
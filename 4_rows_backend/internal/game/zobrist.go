@@ -0,0 +1,35 @@
+package game
+
+// zobristKeys holds one random key per player and bitboard cell.
+// The keys come from a fixed seed so hashes are stable across restarts.
+var zobristKeys = func() [2][MaxCols * (MaxRows + 1)]uint64 {
+	var keys [2][MaxCols * (MaxRows + 1)]uint64
+	state := uint64(0x4f52_4f57_5334_0001)
+	for p := range keys {
+		for i := range keys[p] {
+			state = splitMix64(state)
+			keys[p][i] = state
+		}
+	}
+	return keys
+}()
+
+// secondPlayerKey is mixed into position keys when player 2 is to move
+const secondPlayerKey = 0x6a09e667f3bcc909
+
+// dimensionsKey seeds the hash of an empty board so that boards of different
+// sizes, whose cells share bit indexes, hash differently
+func dimensionsKey(d Dimensions) uint64 {
+	if d == ClassicDimensions {
+		return 0
+	}
+	return splitMix64(uint64(d.Rows) | uint64(d.Cols)<<8 | uint64(d.Connect)<<16)
+}
+
+// splitMix64 is a small, well distributed generator used only to fill zobristKeys
+func splitMix64(state uint64) uint64 {
+	z := state + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -1 +1,175 @@
 package reconnect
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGracePeriod is how long a disconnected player's slot is held
+	DefaultGracePeriod = 60 * time.Second
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid resume token")
+)
+
+// Claims identifies the room slot a resume token grants access to
+type Claims struct {
+	RoomCode  string
+	PlayerNum int
+	PlayerID  string
+}
+
+// Manager issues resume tokens and tracks the grace timers of disconnected players
+type Manager struct {
+	secret []byte
+	grace  time.Duration
+	holds  map[string]*time.Timer
+	mu     sync.Mutex
+}
+
+var (
+	manager     *Manager
+	managerOnce sync.Once
+)
+
+// NewManager creates the global reconnect manager. Call it before the server
+// starts handling connections.
+// If secret is empty a random one is generated, which means tokens
+// will not survive a server restart.
+func NewManager(secret []byte, grace time.Duration) *Manager {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+
+	manager = &Manager{
+		secret: secret,
+		grace:  grace,
+		holds:  make(map[string]*time.Timer),
+	}
+	return manager
+}
+
+// GetManager returns the global reconnect manager, creating one with a random secret if needed
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		if manager == nil {
+			NewManager(nil, DefaultGracePeriod)
+		}
+	})
+	return manager
+}
+
+// GracePeriod returns how long disconnected slots are held
+func (m *Manager) GracePeriod() time.Duration {
+	return m.grace
+}
+
+// IssueToken returns a signed token that lets a new connection take over a player slot
+func (m *Manager) IssueToken(roomCode string, playerNum int, playerID string) string {
+	payload := strings.Join([]string{roomCode, strconv.Itoa(playerNum), playerID}, ":")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + m.sign(encoded)
+}
+
+// ParseToken verifies a token's signature and returns its claims
+func (m *Manager) ParseToken(token string) (Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(m.sign(encoded))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	playerNum, err := strconv.Atoi(parts[1])
+	if err != nil || playerNum < 1 || playerNum > 2 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return Claims{
+		RoomCode:  parts[0],
+		PlayerNum: playerNum,
+		PlayerID:  parts[2],
+	}, nil
+}
+
+// Hold starts the grace timer for a disconnected player.
+// onExpire runs if Resume is not called for the slot before the grace period ends.
+func (m *Manager) Hold(roomCode string, playerNum int, onExpire func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := holdKey(roomCode, playerNum)
+	if timer, ok := m.holds[key]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(m.grace, func() {
+		m.mu.Lock()
+		current := m.holds[key]
+		if current == timer {
+			delete(m.holds, key)
+		}
+		m.mu.Unlock()
+
+		if current == timer {
+			onExpire()
+		}
+	})
+	m.holds[key] = timer
+}
+
+// Resume cancels the grace timer for a slot, returning true if one was pending
+func (m *Manager) Resume(roomCode string, playerNum int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := holdKey(roomCode, playerNum)
+	timer, ok := m.holds[key]
+	if ok {
+		timer.Stop()
+		delete(m.holds, key)
+	}
+	return ok
+}
+
+// Release cancels every pending grace timer for a room
+func (m *Manager) Release(roomCode string) {
+	for playerNum := 1; playerNum <= 2; playerNum++ {
+		m.Resume(roomCode, playerNum)
+	}
+}
+
+func (m *Manager) sign(encoded string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func holdKey(roomCode string, playerNum int) string {
+	return fmt.Sprintf("%s:%d", roomCode, playerNum)
+}
@@ -0,0 +1,48 @@
+package reconnect
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	m := NewManager([]byte("test secret"), time.Minute)
+	token := m.IssueToken("ABC123", 2, "player:with:colons")
+
+	claims, err := m.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Claims{RoomCode: "ABC123", PlayerNum: 2, PlayerID: "player:with:colons"}
+	if claims != want {
+		t.Errorf("claims = %+v, want %+v", claims, want)
+	}
+}
+
+func TestParseTokenErrors(t *testing.T) {
+	m := NewManager([]byte("test secret"), time.Minute)
+	other := NewManager([]byte("other secret"), time.Minute)
+	token := m.IssueToken("ABC123", 1, "player")
+	encoded, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", encoded},
+		{"tampered payload", other.IssueToken("ABC123", 2, "player")[:len(encoded)] + "." + sig},
+		{"other secret", other.IssueToken("ABC123", 1, "player")},
+		{"bad player number", m.IssueToken("ABC123", 3, "player")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.ParseToken(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package solver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Opening book file layout (little endian):
+//
+//	magic   [4]byte "C4BK"
+//	version uint8
+//	width   uint8
+//	height  uint8
+//	depth   uint8   positions with up to depth discs are included
+//	count   uint32
+//	count × { key uint64, score int8 }   sorted by key
+//
+// Keys are canonical (see Position.CanonicalKey), so mirrored positions share an entry.
+
+var bookMagic = [4]byte{'C', '4', 'B', 'K'}
+
+const bookVersion = 1
+
+var ErrInvalidBook = errors.New("invalid opening book")
+
+// BookEntry is the solved score of one position
+type BookEntry struct {
+	Key   uint64
+	Score int8
+}
+
+// Book is a sorted table of solved early positions
+type Book struct {
+	depth   int
+	entries []BookEntry
+}
+
+// NewBook builds a book from solved entries covering positions with up to depth discs
+func NewBook(depth int, entries []BookEntry) *Book {
+	sorted := append([]BookEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return &Book{depth: depth, entries: sorted}
+}
+
+// Depth returns the largest number of discs of the positions in the book
+func (b *Book) Depth() int {
+	return b.depth
+}
+
+// Len returns the number of positions in the book
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// Score returns the stored score of a position for the player to move
+func (b *Book) Score(p Position) (int, bool) {
+	if p.moves > b.depth {
+		return 0, false
+	}
+
+	key := p.CanonicalKey()
+	i := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].Key >= key })
+	if i < len(b.entries) && b.entries[i].Key == key {
+		return int(b.entries[i].Score), true
+	}
+	return 0, false
+}
+
+// WriteTo writes the book in its binary format
+func (b *Book) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := struct {
+		Magic   [4]byte
+		Version uint8
+		Width   uint8
+		Height  uint8
+		Depth   uint8
+		Count   uint32
+	}{bookMagic, bookVersion, Width, Height, uint8(b.depth), uint32(len(b.entries))}
+
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+	for _, entry := range b.entries {
+		if err := binary.Write(bw, binary.LittleEndian, entry); err != nil {
+			return 0, err
+		}
+	}
+
+	n := int64(binary.Size(header)) + int64(len(b.entries)*binary.Size(BookEntry{}))
+	return n, bw.Flush()
+}
+
+// ReadBook parses a book written by WriteTo
+func ReadBook(r io.Reader) (*Book, error) {
+	br := bufio.NewReader(r)
+
+	var header struct {
+		Magic   [4]byte
+		Version uint8
+		Width   uint8
+		Height  uint8
+		Depth   uint8
+		Count   uint32
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBook, err)
+	}
+	if header.Magic != bookMagic || header.Version != bookVersion {
+		return nil, ErrInvalidBook
+	}
+	if header.Width != Width || header.Height != Height {
+		return nil, fmt.Errorf("%w: book is for %dx%d boards", ErrInvalidBook, header.Width, header.Height)
+	}
+
+	entries := make([]BookEntry, header.Count)
+	if err := binary.Read(br, binary.LittleEndian, entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBook, err)
+	}
+
+	return &Book{depth: int(header.Depth), entries: entries}, nil
+}
+
+// LoadBook reads a book from a file
+func LoadBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBook(f)
+}
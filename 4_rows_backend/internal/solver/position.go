@@ -0,0 +1,160 @@
+package solver
+
+import (
+	"math/bits"
+
+	"4_rows_backend/internal/game"
+)
+
+// The solver only handles the classic board
+const (
+	Width  = game.DefaultCols
+	Height = game.DefaultRows
+
+	// MinScore and MaxScore bound the score of any position that is not won on the next move
+	MinScore = -(Width*Height)/2 + 3
+	MaxScore = (Width*Height+1)/2 - 3
+)
+
+const colStride = Height + 1
+
+var (
+	bottomMask = func() uint64 {
+		var mask uint64
+		for col := 0; col < Width; col++ {
+			mask |= bottomMaskCol(col)
+		}
+		return mask
+	}()
+	boardMask = bottomMask * (1<<Height - 1)
+)
+
+// Position is a bitboard seen from the player to move.
+// It uses the same layout as game.Board: column c covers bits
+// c*(Height+1) to c*(Height+1)+Height-1, bottom cell first.
+type Position struct {
+	current uint64 // discs of the player to move
+	mask    uint64 // discs of both players
+	moves   int
+}
+
+// FromBoard converts a board into a Position with player to move.
+// The board must have classic dimensions, see game.Board.IsClassic.
+func FromBoard(board *game.Board, player int) Position {
+	p1, p2 := board.Bitboards()
+	current := p1
+	if player == 2 {
+		current = p2
+	}
+	return Position{
+		current: current,
+		mask:    p1 | p2,
+		moves:   board.MoveCount(),
+	}
+}
+
+// Moves returns the number of discs on the board
+func (p *Position) Moves() int {
+	return p.moves
+}
+
+// CanPlay reports whether the column has room for another disc
+func (p *Position) CanPlay(col int) bool {
+	return col >= 0 && col < Width && p.mask&topMaskCol(col) == 0
+}
+
+// Play drops a disc for the player to move; the column must be playable
+func (p *Position) Play(col int) {
+	p.playMove((p.mask + bottomMaskCol(col)) & columnMask(col))
+}
+
+// IsWinningMove reports whether playing the column connects four for the player to move
+func (p *Position) IsWinningMove(col int) bool {
+	return p.winningPosition()&p.possible()&columnMask(col) != 0
+}
+
+// Key uniquely identifies the position and side to move
+func (p *Position) Key() uint64 {
+	return p.current + p.mask
+}
+
+// CanonicalKey is the smaller of the keys of the position and its mirror image
+func (p *Position) CanonicalKey() uint64 {
+	key := p.Key()
+	var mirrored uint64
+	for col := 0; col < Width; col++ {
+		segment := (key >> (col * colStride)) & (1<<colStride - 1)
+		mirrored |= segment << ((Width - 1 - col) * colStride)
+	}
+	return min(key, mirrored)
+}
+
+func (p *Position) playMove(move uint64) {
+	p.current ^= p.mask
+	p.mask |= move
+	p.moves++
+}
+
+func (p *Position) canWinNext() bool {
+	return p.winningPosition()&p.possible() != 0
+}
+
+// possibleNonLosingMoves returns the playable cells that do not hand the opponent an immediate win
+func (p *Position) possibleNonLosingMoves() uint64 {
+	possible := p.possible()
+	opponentWin := p.opponentWinningPosition()
+	forced := possible & opponentWin
+	if forced != 0 {
+		if forced&(forced-1) != 0 {
+			return 0 // two threats to block, the game is lost
+		}
+		possible = forced
+	}
+	return possible &^ (opponentWin >> 1)
+}
+
+// moveScore counts the winning cells a move creates, used for move ordering
+func (p *Position) moveScore(move uint64) int {
+	return bits.OnesCount64(computeWinningPosition(p.current|move, p.mask))
+}
+
+func (p *Position) winningPosition() uint64 {
+	return computeWinningPosition(p.current, p.mask)
+}
+
+func (p *Position) opponentWinningPosition() uint64 {
+	return computeWinningPosition(p.current^p.mask, p.mask)
+}
+
+func (p *Position) possible() uint64 {
+	return (p.mask + bottomMask) & boardMask
+}
+
+// computeWinningPosition returns the empty cells that would complete four for position
+func computeWinningPosition(position, mask uint64) uint64 {
+	// vertical
+	r := (position << 1) & (position << 2) & (position << 3)
+
+	for _, shift := range [3]uint{colStride, colStride - 1, colStride + 1} {
+		pair := (position << shift) & (position << (2 * shift))
+		r |= pair & (position << (3 * shift))
+		r |= pair & (position >> shift)
+		pair = (position >> shift) & (position >> (2 * shift))
+		r |= pair & (position << shift)
+		r |= pair & (position >> (3 * shift))
+	}
+
+	return r & (boardMask ^ mask)
+}
+
+func topMaskCol(col int) uint64 {
+	return 1 << (Height - 1) << (col * colStride)
+}
+
+func bottomMaskCol(col int) uint64 {
+	return 1 << (col * colStride)
+}
+
+func columnMask(col int) uint64 {
+	return (1<<Height - 1) << (col * colStride)
+}
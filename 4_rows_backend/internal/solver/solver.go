@@ -0,0 +1,244 @@
+package solver
+
+import (
+	"errors"
+	"time"
+)
+
+// Scores follow the usual convention for solved connect-four positions:
+// 0 is a draw, a positive score means the player to move wins and a negative
+// score means they lose. The magnitude is the number of discs the winner still
+// has in hand when the game ends, so faster wins score higher.
+
+// InvalidScore marks columns that cannot be played in Analyze results
+const InvalidScore = -1000
+
+// nodesPerDeadlineCheck controls how often the search looks at the clock
+const nodesPerDeadlineCheck = 4096
+
+var ErrTimeout = errors.New("solver ran out of time")
+
+// Solver computes exact game-theoretic values of positions.
+// A Solver is not safe for concurrent use.
+type Solver struct {
+	table    *table
+	nodes    uint64
+	deadline time.Time
+	timedOut bool
+}
+
+// columnOrder explores center columns first
+var columnOrder = func() [Width]int {
+	var order [Width]int
+	for i := range order {
+		order[i] = Width/2 + (1-2*(i%2))*(i+1)/2
+	}
+	return order
+}()
+
+// New creates a solver with a transposition table of DefaultTableSize entries
+func New() *Solver {
+	return NewWithTableSize(DefaultTableSize)
+}
+
+// NewWithTableSize creates a solver with a custom table size, which should be a prime
+func NewWithTableSize(size int) *Solver {
+	return &Solver{table: newTable(size)}
+}
+
+// Nodes returns the number of positions explored since the solver was created
+func (s *Solver) Nodes() uint64 {
+	return s.nodes
+}
+
+// Solve returns the exact score of a position in which nobody has won yet.
+// With weak set only the sign of the result is computed, which is much faster.
+func (s *Solver) Solve(p Position, weak bool) int {
+	score, _ := s.SolveWithin(p, weak, time.Time{})
+	return score
+}
+
+// SolveWithin is like Solve but gives up with ErrTimeout once the deadline passes.
+// A zero deadline means no limit.
+func (s *Solver) SolveWithin(p Position, weak bool, deadline time.Time) (int, error) {
+	s.deadline = deadline
+	s.timedOut = false
+
+	if p.canWinNext() {
+		return (Width*Height + 1 - p.moves) / 2, nil
+	}
+
+	lo := -(Width*Height - p.moves) / 2
+	hi := (Width*Height + 1 - p.moves) / 2
+	if weak {
+		lo, hi = -1, 1
+	}
+
+	// Narrow the window with null-window searches until it closes on the exact score
+	for lo < hi {
+		med := lo + (hi-lo)/2
+		if med <= 0 && lo/2 < med {
+			med = lo / 2
+		} else if med >= 0 && hi/2 > med {
+			med = hi / 2
+		}
+
+		r := s.negamax(p, med, med+1)
+		if s.timedOut {
+			return 0, ErrTimeout
+		}
+		if r <= med {
+			hi = r
+		} else {
+			lo = r
+		}
+	}
+	return lo, nil
+}
+
+// Analyze returns the score of every column for the player to move,
+// with InvalidScore for columns that are full.
+func (s *Solver) Analyze(p Position, weak bool) [Width]int {
+	scores, _ := s.AnalyzeWithin(p, weak, time.Time{})
+	return scores
+}
+
+// AnalyzeWithin is like Analyze but gives up with ErrTimeout once the deadline passes
+func (s *Solver) AnalyzeWithin(p Position, weak bool, deadline time.Time) ([Width]int, error) {
+	var scores [Width]int
+	for col := 0; col < Width; col++ {
+		switch {
+		case !p.CanPlay(col):
+			scores[col] = InvalidScore
+		case p.IsWinningMove(col):
+			scores[col] = (Width*Height + 1 - p.moves) / 2
+		default:
+			child := p
+			child.Play(col)
+			score, err := s.SolveWithin(child, weak, deadline)
+			if err != nil {
+				return scores, err
+			}
+			scores[col] = -score
+		}
+	}
+	return scores, nil
+}
+
+// BestMove returns the strongest column and its score, preferring central columns on ties
+func BestMove(scores [Width]int) (int, int) {
+	best, bestScore := -1, InvalidScore
+	for _, col := range columnOrder {
+		if scores[col] != InvalidScore && (best < 0 || scores[col] > bestScore) {
+			best, bestScore = col, scores[col]
+		}
+	}
+	return best, bestScore
+}
+
+// negamax searches a position that cannot be won on the next move within the (alpha, beta) window
+func (s *Solver) negamax(p Position, alpha, beta int) int {
+	s.nodes++
+	if s.nodes%nodesPerDeadlineCheck == 0 && !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.timedOut = true
+	}
+	if s.timedOut {
+		return 0
+	}
+
+	next := p.possibleNonLosingMoves()
+	if next == 0 {
+		return -(Width*Height - p.moves) / 2
+	}
+	if p.moves >= Width*Height-2 {
+		return 0
+	}
+
+	lo := -(Width*Height - 2 - p.moves) / 2
+	if alpha < lo {
+		alpha = lo
+		if alpha >= beta {
+			return alpha
+		}
+	}
+
+	hi := (Width*Height - 1 - p.moves) / 2
+	key := p.Key()
+	if value := int(s.table.get(key)); value != 0 {
+		if value > MaxScore-MinScore+1 {
+			lo = value + 2*MinScore - MaxScore - 2
+			if alpha < lo {
+				alpha = lo
+				if alpha >= beta {
+					return alpha
+				}
+			}
+		} else {
+			hi = value + MinScore - 1
+		}
+	}
+	if beta > hi {
+		beta = hi
+		if alpha >= beta {
+			return beta
+		}
+	}
+
+	var moves moveSorter
+	for i := Width - 1; i >= 0; i-- {
+		if move := next & columnMask(columnOrder[i]); move != 0 {
+			moves.add(move, p.moveScore(move))
+		}
+	}
+
+	for move := moves.next(); move != 0; move = moves.next() {
+		child := p
+		child.playMove(move)
+		score := -s.negamax(child, -beta, -alpha)
+		if s.timedOut {
+			return 0
+		}
+
+		if score >= beta {
+			// Lower bound, stored above the upper bound range
+			s.table.put(key, uint8(score+MaxScore-2*MinScore+2))
+			return score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	// Upper bound
+	s.table.put(key, uint8(alpha-MinScore+1))
+	return alpha
+}
+
+// moveSorter keeps up to Width moves ordered by score; equal scores
+// come out in reverse insertion order
+type moveSorter struct {
+	size    int
+	entries [Width]struct {
+		move  uint64
+		score int
+	}
+}
+
+func (m *moveSorter) add(move uint64, score int) {
+	pos := m.size
+	m.size++
+	for ; pos > 0 && m.entries[pos-1].score > score; pos-- {
+		m.entries[pos] = m.entries[pos-1]
+	}
+	m.entries[pos].move = move
+	m.entries[pos].score = score
+}
+
+// next returns the best remaining move, or 0 when there are none left
+func (m *moveSorter) next() uint64 {
+	if m.size == 0 {
+		return 0
+	}
+	m.size--
+	return m.entries[m.size].move
+}
@@ -0,0 +1,141 @@
+package solver
+
+import (
+	"bytes"
+	"testing"
+
+	"4_rows_backend/internal/game"
+)
+
+// sampleGame is a full drawn game; its prefixes give late positions small
+// enough to check against a plain search
+var sampleGame = []int{3, 2, 3, 1, 2, 6, 5, 3, 3, 4, 6, 5, 4, 3, 2, 2, 0, 2, 2, 4, 3, 1, 1, 1, 1, 5, 0, 5, 5, 4, 0, 5, 6, 4, 6, 0, 4, 1, 0, 6, 0, 6}
+
+// position plays the columns from the empty board
+func position(t *testing.T, columns []int) Position {
+	t.Helper()
+	board := game.NewBoard(game.ClassicDimensions)
+	for m, col := range columns {
+		if _, ok := board.Drop(col, m%2+1); !ok {
+			t.Fatalf("move %d: column %d is full", m+1, col)
+		}
+	}
+	return FromBoard(&board, len(columns)%2+1)
+}
+
+// bruteForce scores a position by searching every move, without pruning
+func bruteForce(p Position) int {
+	if p.Moves() == Width*Height {
+		return 0
+	}
+	best := InvalidScore
+	for col := 0; col < Width; col++ {
+		if !p.CanPlay(col) {
+			continue
+		}
+		if p.IsWinningMove(col) {
+			return (Width*Height + 1 - p.Moves()) / 2
+		}
+		child := p
+		child.Play(col)
+		best = max(best, -bruteForce(child))
+	}
+	return best
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+func TestSolveKnownPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []int
+		score   int
+	}{
+		{"win on the next move", []int{3, 0, 3, 0, 3, 1}, 18},
+		{"loss against an open three", []int{0, 2, 6, 3, 6, 4}, -18},
+		{"double threat", []int{3, 3, 2, 2, 6, 6}, 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := position(t, tt.columns)
+			if got := New().Solve(p, false); got != tt.score {
+				t.Errorf("Solve = %d, want %d", got, tt.score)
+			}
+			if got := sign(New().Solve(p, true)); got != sign(tt.score) {
+				t.Errorf("weak Solve has sign %d, want %d", got, sign(tt.score))
+			}
+		})
+	}
+}
+
+func TestSolveMatchesBruteForce(t *testing.T) {
+	for _, moves := range []int{30, 32, 34, 36, 38, 41} {
+		p := position(t, sampleGame[:moves])
+		want := bruteForce(p)
+		if got := New().Solve(p, false); got != want {
+			t.Errorf("after %d moves: Solve = %d, want %d", moves, got, want)
+		}
+		if got := sign(New().Solve(p, true)); got != sign(want) {
+			t.Errorf("after %d moves: weak Solve has sign %d, want %d", moves, got, sign(want))
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	p := position(t, sampleGame[:34])
+	scores := New().Analyze(p, false)
+	for col, score := range scores {
+		want := InvalidScore
+		switch {
+		case !p.CanPlay(col):
+		case p.IsWinningMove(col):
+			want = (Width*Height + 1 - p.Moves()) / 2
+		default:
+			child := p
+			child.Play(col)
+			want = -bruteForce(child)
+		}
+		if score != want {
+			t.Errorf("column %d scores %d, want %d", col+1, score, want)
+		}
+	}
+
+	scores = New().Analyze(position(t, []int{3, 0, 3, 0, 3, 1}), false)
+	if best, score := BestMove(scores); best != 3 || score != 18 {
+		t.Errorf("BestMove = column %d (%d), want column 4 (18)", best+1, score)
+	}
+}
+
+func TestBookRoundTrip(t *testing.T) {
+	p := position(t, []int{3, 2})
+	mirrored := position(t, []int{3, 4})
+	book := NewBook(4, []BookEntry{{Key: p.CanonicalKey(), Score: 2}, {Key: 1, Score: -1}})
+
+	var buf bytes.Buffer
+	if _, err := book.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBook(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Depth() != book.Depth() || read.Len() != book.Len() {
+		t.Fatalf("read depth %d len %d, want %d and %d", read.Depth(), read.Len(), book.Depth(), book.Len())
+	}
+	if score, ok := read.Score(mirrored); !ok || score != 2 {
+		t.Errorf("Score = %d, %v; want 2, true", score, ok)
+	}
+
+	if _, err := ReadBook(bytes.NewReader([]byte("C4BX"))); err == nil {
+		t.Error("ReadBook accepted a bad magic number")
+	}
+}
@@ -0,0 +1,34 @@
+package solver
+
+// DefaultTableSize is a prime just below 2^23 entries (about 40MB).
+// Together with the 32 bit partial keys it distinguishes every 7x6 position key.
+const DefaultTableSize = 8388593
+
+// table caches score bounds by position key. Only the low 32 bits of the key
+// are stored; the index (key modulo a prime) recovers the rest.
+type table struct {
+	keys   []uint32
+	values []uint8
+}
+
+func newTable(size int) *table {
+	return &table{
+		keys:   make([]uint32, size),
+		values: make([]uint8, size),
+	}
+}
+
+func (t *table) put(key uint64, value uint8) {
+	i := key % uint64(len(t.keys))
+	t.keys[i] = uint32(key)
+	t.values[i] = value
+}
+
+// get returns the stored value for key, or 0 if there is none
+func (t *table) get(key uint64) uint8 {
+	i := key % uint64(len(t.keys))
+	if t.keys[i] == uint32(key) {
+		return t.values[i]
+	}
+	return 0
+}
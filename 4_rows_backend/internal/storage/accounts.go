@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrUsernameTaken = errors.New("username is already taken")
+	ErrGuestUpgraded = errors.New("guest already has an account")
+)
+
+// AccountData represents a row in the accounts table
+type AccountData struct {
+	ID           string
+	Username     string
+	PasswordHash string
+	GuestID      string // the guest the account was upgraded from, if any
+	CreatedAt    time.Time
+}
+
+// SessionData represents a row in the sessions table. Only a hash of the
+// session token is stored, so a leaked database cannot be used to log in.
+type SessionData struct {
+	TokenHash string
+	AccountID string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// CreateAccount stores a new account, returning ErrUsernameTaken if the
+// username is in use, ignoring case. An account upgraded from a guest takes
+// over the guest's archived games; ErrGuestUpgraded means the guest already
+// has an account.
+func (s *SQLiteStorage) CreateAccount(account *AccountData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var guestID sql.NullString
+	if account.GuestID != "" {
+		guestID = sql.NullString{String: account.GuestID, Valid: true}
+	}
+	_, err = tx.Exec(
+		"INSERT INTO accounts (id, username, password_hash, guest_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		account.ID, account.Username, account.PasswordHash, guestID, account.CreatedAt,
+	)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		if strings.Contains(sqliteErr.Error(), "guest_id") {
+			return ErrGuestUpgraded
+		}
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	if account.GuestID != "" {
+		for _, player := range []string{"player1", "player2"} {
+			_, err := tx.Exec(
+				"UPDATE games SET "+player+"_account_id = ? WHERE "+player+"_guest_id = ?",
+				account.ID, account.GuestID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAccount retrieves an account by ID, returning nil if there is none
+func (s *SQLiteStorage) GetAccount(id string) (*AccountData, error) {
+	return s.queryAccount("SELECT id, username, password_hash, COALESCE(guest_id, ''), created_at FROM accounts WHERE id = ?", id)
+}
+
+// GetAccountByUsername retrieves an account by username, ignoring case, returning nil if there is none
+func (s *SQLiteStorage) GetAccountByUsername(username string) (*AccountData, error) {
+	return s.queryAccount("SELECT id, username, password_hash, COALESCE(guest_id, ''), created_at FROM accounts WHERE username = ?", username)
+}
+
+func (s *SQLiteStorage) queryAccount(query string, arg string) (*AccountData, error) {
+	var account AccountData
+	err := s.db.QueryRow(query, arg).Scan(&account.ID, &account.Username, &account.PasswordHash, &account.GuestID, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetUnpublishedUpgrades returns the accounts upgraded from a guest whose
+// upgrade has not been sent to analytics yet, oldest first
+func (s *SQLiteStorage) GetUnpublishedUpgrades() ([]AccountData, error) {
+	rows, err := s.db.Query(
+		"SELECT id, username, password_hash, guest_id, created_at FROM accounts WHERE guest_id IS NOT NULL AND upgrade_published = 0 ORDER BY created_at",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var upgrades []AccountData
+	for rows.Next() {
+		var account AccountData
+		if err := rows.Scan(&account.ID, &account.Username, &account.PasswordHash, &account.GuestID, &account.CreatedAt); err != nil {
+			return nil, err
+		}
+		upgrades = append(upgrades, account)
+	}
+	return upgrades, rows.Err()
+}
+
+// MarkUpgradePublished records that analytics has been told about an account's guest upgrade
+func (s *SQLiteStorage) MarkUpgradePublished(accountID string) error {
+	_, err := s.db.Exec("UPDATE accounts SET upgrade_published = 1 WHERE id = ?", accountID)
+	return err
+}
+
+// CreateSession stores a login session
+func (s *SQLiteStorage) CreateSession(session *SessionData) error {
+	_, err := s.db.Exec(
+		"INSERT INTO sessions (token_hash, account_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		session.TokenHash, session.AccountID, session.CreatedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+// GetSession retrieves a session by token hash, returning nil if there is none
+func (s *SQLiteStorage) GetSession(tokenHash string) (*SessionData, error) {
+	var session SessionData
+	err := s.db.QueryRow(
+		"SELECT token_hash, account_id, created_at, expires_at FROM sessions WHERE token_hash = ?", tokenHash,
+	).Scan(&session.TokenHash, &session.AccountID, &session.CreatedAt, &session.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession removes a session, logging it out
+func (s *SQLiteStorage) DeleteSession(tokenHash string) error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+// DeleteExpiredSessions removes sessions past their expiry time
+func (s *SQLiteStorage) DeleteExpiredSessions() (int64, error) {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
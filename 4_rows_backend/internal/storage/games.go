@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// GameData represents a finished game in the games table
+type GameData struct {
+	ID             string
+	RoomCode       string
+	Variant        string
+	Rows           int
+	Cols           int
+	Connect        int
+	Player1Name    string
+	Player2Name    string
+	Player1Account string // account IDs, empty for guests
+	Player2Account string
+	Player1Guest   string // guest IDs, kept after the guest registers
+	Player2Guest   string
+	Winner         int
+	Reason         string
+	IsBotGame      bool
+	BotLevel       string
+	Rated          bool
+	TimeControl    TimeControlData
+	Start          string // position notation of a seeded start, empty for the variant's own
+	Moves          []MoveData
+	StartedAt      time.Time
+	EndedAt        time.Time
+}
+
+// SaveGame archives a finished game; saving the same game again replaces it
+func (s *SQLiteStorage) SaveGame(game *GameData) error {
+	movesJSON, err := json.Marshal(game.Moves)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT OR REPLACE INTO games
+		(id, room_code, variant, rows, cols, connect_length, player1_name, player2_name, player1_account_id, player2_account_id, player1_guest_id, player2_guest_id, winner, reason, is_bot_game, bot_level, rated, time_initial_ms, time_increment_ms, time_per_move_ms, start_position, moves, started_at, ended_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(query,
+		game.ID,
+		game.RoomCode,
+		game.Variant,
+		game.Rows,
+		game.Cols,
+		game.Connect,
+		game.Player1Name,
+		game.Player2Name,
+		game.Player1Account,
+		game.Player2Account,
+		game.Player1Guest,
+		game.Player2Guest,
+		game.Winner,
+		game.Reason,
+		boolToInt(game.IsBotGame),
+		game.BotLevel,
+		boolToInt(game.Rated),
+		game.TimeControl.Initial.Milliseconds(),
+		game.TimeControl.Increment.Milliseconds(),
+		game.TimeControl.PerMove.Milliseconds(),
+		game.Start,
+		string(movesJSON),
+		game.StartedAt,
+		game.EndedAt,
+	)
+	return err
+}
+
+// GetGame retrieves an archived game, returning nil if there is none with the ID
+func (s *SQLiteStorage) GetGame(id string) (*GameData, error) {
+	query := `
+	SELECT id, room_code, variant, rows, cols, connect_length, player1_name, player2_name, player1_account_id, player2_account_id, player1_guest_id, player2_guest_id, winner, reason, is_bot_game, bot_level, rated, time_initial_ms, time_increment_ms, time_per_move_ms, start_position, moves, started_at, ended_at
+	FROM games WHERE id = ?
+	`
+
+	var game GameData
+	var movesJSON string
+	var isBotGame, rated int
+	var initialMs, incrementMs, perMoveMs int64
+
+	err := s.db.QueryRow(query, id).Scan(
+		&game.ID,
+		&game.RoomCode,
+		&game.Variant,
+		&game.Rows,
+		&game.Cols,
+		&game.Connect,
+		&game.Player1Name,
+		&game.Player2Name,
+		&game.Player1Account,
+		&game.Player2Account,
+		&game.Player1Guest,
+		&game.Player2Guest,
+		&game.Winner,
+		&game.Reason,
+		&isBotGame,
+		&game.BotLevel,
+		&rated,
+		&initialMs,
+		&incrementMs,
+		&perMoveMs,
+		&game.Start,
+		&movesJSON,
+		&game.StartedAt,
+		&game.EndedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(movesJSON), &game.Moves); err != nil {
+		return nil, err
+	}
+
+	game.IsBotGame = isBotGame == 1
+	game.Rated = rated == 1
+	game.TimeControl = TimeControlData{
+		Initial:   time.Duration(initialMs) * time.Millisecond,
+		Increment: time.Duration(incrementMs) * time.Millisecond,
+		PerMove:   time.Duration(perMoveMs) * time.Millisecond,
+	}
+
+	return &game, nil
+}
@@ -11,19 +11,52 @@ import (
 
 // RoomData represents a row in the rooms table
 type RoomData struct {
-	Code         string
-	Player1ID    string
-	Player1Name  string
-	Player2ID    string
-	Player2Name  string
-	Board        [6][7]int
-	CurrentTurn  int
-	GameStarted  bool
-	GameOver     bool
-	Winner       int
-	IsBotGame    bool
-	CreatedAt    time.Time
-	LastActivity time.Time
+	Code           string
+	GameID         string
+	StartPosition  string // position notation of a seeded start, empty for the variant's own
+	Player1ID      string
+	Player1Name    string
+	Player1Account string // account IDs, empty for guests
+	Player1Guest   string // guest IDs, empty for registered players
+	Player2ID      string
+	Player2Name    string
+	Player2Account string
+	Player2Guest   string
+	Board          [][]int // row 0 is the top, dimensions follow from the grid
+	Connect        int
+	Variant        string
+	Kept           [2]int // discs each player has kept in Pop Ten
+	CurrentTurn    int
+	GameStarted    bool
+	GameOver       bool
+	Winner         int
+	IsBotGame      bool
+	BotLevel       string
+	Rated          bool
+	Public         bool // listed in the lobby while waiting for an opponent
+	TimeControl    TimeControlData
+	Clock          [2]time.Duration // time each player had left when the room was saved
+	Moves          []MoveData       // moves of the current game in the order they were played
+	CreatedAt      time.Time
+	LastActivity   time.Time
+}
+
+// TimeControlData is a room's time control, all zero for an untimed game
+type TimeControlData struct {
+	Initial   time.Duration
+	Increment time.Duration
+	PerMove   time.Duration
+}
+
+// MoveData represents a row in the moves table
+type MoveData struct {
+	Ply       int // 1 for the first move of the game
+	PlayerNum int
+	Action    string // "drop" or "pop"
+	Column    int
+	Row       int
+	Kept      bool
+	PlayedAt  time.Time
 }
 
 // SQLiteStorage implements game state persistence
@@ -65,45 +98,232 @@ func createTables(db *sql.DB) error {
 		last_activity DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS idx_rooms_last_activity ON rooms(last_activity);
+
+	CREATE TABLE IF NOT EXISTS moves (
+		room_code TEXT NOT NULL,
+		ply INTEGER NOT NULL,
+		player INTEGER NOT NULL,
+		action TEXT DEFAULT 'drop',
+		column_index INTEGER NOT NULL,
+		row_index INTEGER NOT NULL,
+		kept INTEGER DEFAULT 0,
+		played_at DATETIME,
+		PRIMARY KEY (room_code, ply)
+	);
+
+	CREATE TABLE IF NOT EXISTS games (
+		id TEXT PRIMARY KEY,
+		room_code TEXT NOT NULL,
+		variant TEXT DEFAULT 'classic',
+		rows INTEGER NOT NULL,
+		cols INTEGER NOT NULL,
+		connect_length INTEGER NOT NULL,
+		player1_name TEXT DEFAULT '',
+		player2_name TEXT DEFAULT '',
+		winner INTEGER DEFAULT 0,
+		reason TEXT DEFAULT '',
+		is_bot_game INTEGER DEFAULT 0,
+		bot_level TEXT DEFAULT '',
+		rated INTEGER DEFAULT 0,
+		time_initial_ms INTEGER DEFAULT 0,
+		time_increment_ms INTEGER DEFAULT 0,
+		time_per_move_ms INTEGER DEFAULT 0,
+		moves TEXT NOT NULL,
+		started_at DATETIME,
+		ended_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_games_ended ON games(ended_at);
+
+	CREATE TABLE IF NOT EXISTS accounts (
+		id TEXT PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
 	`
-	_, err := db.Exec(query)
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	return migrateTables(db)
+}
+
+// migrateTables adds columns introduced after the original schema to existing databases
+func migrateTables(db *sql.DB) error {
+	columns := []struct {
+		table, name, definition string
+	}{
+		{"rooms", "bot_level", "TEXT DEFAULT ''"},
+		{"rooms", "rated", "INTEGER DEFAULT 0"},
+		{"rooms", "connect_length", "INTEGER DEFAULT 4"},
+		{"rooms", "variant", "TEXT DEFAULT 'classic'"},
+		{"rooms", "player1_kept", "INTEGER DEFAULT 0"},
+		{"rooms", "player2_kept", "INTEGER DEFAULT 0"},
+		{"rooms", "time_initial_ms", "INTEGER DEFAULT 0"},
+		{"rooms", "time_increment_ms", "INTEGER DEFAULT 0"},
+		{"rooms", "time_per_move_ms", "INTEGER DEFAULT 0"},
+		{"rooms", "player1_clock_ms", "INTEGER DEFAULT 0"},
+		{"rooms", "player2_clock_ms", "INTEGER DEFAULT 0"},
+		{"rooms", "game_id", "TEXT DEFAULT ''"},
+		{"rooms", "start_position", "TEXT DEFAULT ''"},
+		{"games", "start_position", "TEXT DEFAULT ''"},
+		{"rooms", "public", "INTEGER DEFAULT 0"},
+		{"rooms", "player1_account_id", "TEXT DEFAULT ''"},
+		{"rooms", "player2_account_id", "TEXT DEFAULT ''"},
+		{"games", "player1_account_id", "TEXT DEFAULT ''"},
+		{"games", "player2_account_id", "TEXT DEFAULT ''"},
+		{"accounts", "guest_id", "TEXT"},
+		{"rooms", "player1_guest_id", "TEXT DEFAULT ''"},
+		{"rooms", "player2_guest_id", "TEXT DEFAULT ''"},
+		{"games", "player1_guest_id", "TEXT DEFAULT ''"},
+		{"games", "player2_guest_id", "TEXT DEFAULT ''"},
+		{"accounts", "upgrade_published", "INTEGER DEFAULT 0"},
+	}
+
+	for _, col := range columns {
+		if err := addColumnIfMissing(db, col.table, col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	// A guest can be upgraded to one account only; accounts created directly have a NULL guest_id
+	_, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_guest ON accounts(guest_id)")
 	return err
 }
 
-// SaveRoom saves or updates a room in the database
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
+
+// SaveRoom saves or updates a room and its move list in the database
 func (s *SQLiteStorage) SaveRoom(room *RoomData) error {
 	boardJSON, err := json.Marshal(room.Board)
 	if err != nil {
 		return err
 	}
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 	INSERT OR REPLACE INTO rooms 
-		(code, player1_id, player1_name, player2_id, player2_name, board, current_turn, game_started, game_over, winner, is_bot_game, last_activity)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		(code, game_id, start_position, player1_id, player1_name, player1_account_id, player1_guest_id, player2_id, player2_name, player2_account_id, player2_guest_id, board, current_turn, game_started, game_over, winner, is_bot_game, bot_level, rated, public, connect_length, variant, player1_kept, player2_kept, time_initial_ms, time_increment_ms, time_per_move_ms, player1_clock_ms, player2_clock_ms, created_at, last_activity)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`
 
-	_, err = s.db.Exec(query,
+	_, err = tx.Exec(query,
 		room.Code,
+		room.GameID,
+		room.StartPosition,
 		room.Player1ID,
 		room.Player1Name,
+		room.Player1Account,
+		room.Player1Guest,
 		room.Player2ID,
 		room.Player2Name,
+		room.Player2Account,
+		room.Player2Guest,
 		string(boardJSON),
 		room.CurrentTurn,
 		boolToInt(room.GameStarted),
 		boolToInt(room.GameOver),
 		room.Winner,
 		boolToInt(room.IsBotGame),
+		room.BotLevel,
+		boolToInt(room.Rated),
+		boolToInt(room.Public),
+		room.Connect,
+		room.Variant,
+		room.Kept[0],
+		room.Kept[1],
+		room.TimeControl.Initial.Milliseconds(),
+		room.TimeControl.Increment.Milliseconds(),
+		room.TimeControl.PerMove.Milliseconds(),
+		room.Clock[0].Milliseconds(),
+		room.Clock[1].Milliseconds(),
+		room.CreatedAt,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Takebacks and rematches shorten the list, so it is rewritten as a whole
+	if _, err := tx.Exec("DELETE FROM moves WHERE room_code = ?", room.Code); err != nil {
+		return err
+	}
+	for _, move := range room.Moves {
+		_, err := tx.Exec(
+			"INSERT INTO moves (room_code, ply, player, action, column_index, row_index, kept, played_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			room.Code, move.Ply, move.PlayerNum, move.Action, move.Column, move.Row, boolToInt(move.Kept), move.PlayedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMoves returns the moves stored for a room in the order they were played
+func (s *SQLiteStorage) GetMoves(code string) ([]MoveData, error) {
+	rows, err := s.db.Query(
+		"SELECT ply, player, action, column_index, row_index, kept, played_at FROM moves WHERE room_code = ? ORDER BY ply",
+		code,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []MoveData
+	for rows.Next() {
+		var move MoveData
+		var kept int
+		if err := rows.Scan(&move.Ply, &move.PlayerNum, &move.Action, &move.Column, &move.Row, &kept, &move.PlayedAt); err != nil {
+			return nil, err
+		}
+		move.Kept = kept == 1
+		moves = append(moves, move)
+	}
+
+	return moves, rows.Err()
 }
 
 // GetRoom retrieves a room from the database
 func (s *SQLiteStorage) GetRoom(code string) (*RoomData, error) {
 	query := `
-	SELECT code, player1_id, player1_name, player2_id, player2_name, board, current_turn, game_started, game_over, winner, is_bot_game, created_at, last_activity
+	SELECT code, game_id, start_position, player1_id, player1_name, player1_account_id, player1_guest_id, player2_id, player2_name, player2_account_id, player2_guest_id, board, current_turn, game_started, game_over, winner, is_bot_game, bot_level, rated, public, connect_length, variant, player1_kept, player2_kept, time_initial_ms, time_increment_ms, time_per_move_ms, player1_clock_ms, player2_clock_ms, created_at, last_activity
 	FROM rooms WHERE code = ?
 	`
 
@@ -111,20 +331,39 @@ func (s *SQLiteStorage) GetRoom(code string) (*RoomData, error) {
 
 	var room RoomData
 	var boardJSON string
-	var gameStarted, gameOver, isBotGame int
+	var gameStarted, gameOver, isBotGame, rated, public int
+	var initialMs, incrementMs, perMoveMs, clock1Ms, clock2Ms int64
 
 	err := row.Scan(
 		&room.Code,
+		&room.GameID,
+		&room.StartPosition,
 		&room.Player1ID,
 		&room.Player1Name,
+		&room.Player1Account,
+		&room.Player1Guest,
 		&room.Player2ID,
 		&room.Player2Name,
+		&room.Player2Account,
+		&room.Player2Guest,
 		&boardJSON,
 		&room.CurrentTurn,
 		&gameStarted,
 		&gameOver,
 		&room.Winner,
 		&isBotGame,
+		&room.BotLevel,
+		&rated,
+		&public,
+		&room.Connect,
+		&room.Variant,
+		&room.Kept[0],
+		&room.Kept[1],
+		&initialMs,
+		&incrementMs,
+		&perMoveMs,
+		&clock1Ms,
+		&clock2Ms,
 		&room.CreatedAt,
 		&room.LastActivity,
 	)
@@ -144,12 +383,30 @@ func (s *SQLiteStorage) GetRoom(code string) (*RoomData, error) {
 	room.GameStarted = gameStarted == 1
 	room.GameOver = gameOver == 1
 	room.IsBotGame = isBotGame == 1
+	room.Rated = rated == 1
+	room.Public = public == 1
+	room.TimeControl = TimeControlData{
+		Initial:   time.Duration(initialMs) * time.Millisecond,
+		Increment: time.Duration(incrementMs) * time.Millisecond,
+		PerMove:   time.Duration(perMoveMs) * time.Millisecond,
+	}
+	room.Clock = [2]time.Duration{
+		time.Duration(clock1Ms) * time.Millisecond,
+		time.Duration(clock2Ms) * time.Millisecond,
+	}
+
+	if room.Moves, err = s.GetMoves(code); err != nil {
+		return nil, err
+	}
 
 	return &room, nil
 }
 
 // DeleteRoom removes a room from the database
 func (s *SQLiteStorage) DeleteRoom(code string) error {
+	if _, err := s.db.Exec("DELETE FROM moves WHERE room_code = ?", code); err != nil {
+		return err
+	}
 	_, err := s.db.Exec("DELETE FROM rooms WHERE code = ?", code)
 	return err
 }
@@ -166,6 +423,10 @@ func (s *SQLiteStorage) DeleteInactiveRooms(maxAge time.Duration) (int64, error)
 		return 0, err
 	}
 
+	if _, err := s.db.Exec("DELETE FROM moves WHERE room_code NOT IN (SELECT code FROM rooms)"); err != nil {
+		return 0, err
+	}
+
 	return result.RowsAffected()
 }
 
@@ -178,8 +439,8 @@ func (s *SQLiteStorage) UpdateLastActivity(code string) error {
 	return err
 }
 
-// GetAllRoomCodes returns all room codes (for cache warming on startup)
-func (s *SQLiteStorage) GetAllRoomCodes() ([]string, error) {
+// GetUnfinishedRoomCodes returns the codes of rooms whose game is not over, for restoring them on startup
+func (s *SQLiteStorage) GetUnfinishedRoomCodes() ([]string, error) {
 	rows, err := s.db.Query("SELECT code FROM rooms WHERE game_over = 0")
 	if err != nil {
 		return nil, err
@@ -214,6 +475,10 @@ func (s *SQLiteStorage) StartCleanupRoutine(interval, maxAge time.Duration) {
 			} else if deleted > 0 {
 				log.Printf("Cleaned up %d inactive rooms", deleted)
 			}
+
+			if _, err := s.DeleteExpiredSessions(); err != nil {
+				log.Printf("Error during session cleanup: %v", err)
+			}
 		}
 	}()
 }
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"4_rows_backend/internal/accounts"
+	"4_rows_backend/internal/events"
+)
+
+// AccountResponse is a registered player as returned by the account endpoints
+type AccountResponse struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	GuestID   string    `json:"guest_id,omitempty"` // the guest the account was upgraded from
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionResponse is returned on register and login. The token authenticates
+// API requests as a bearer token and the WebSocket as /ws?token=...
+type SessionResponse struct {
+	Account AccountResponse `json:"account"`
+	Token   string          `json:"token"`
+}
+
+type credentialsRequest struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	GuestToken string `json:"guest_token,omitempty"` // register only: the guest to upgrade
+}
+
+// HandleRegister serves POST /api/accounts, creating an account and logging it
+// in. A guest registering with their guest token, in the body or the guest
+// cookie, is upgraded and keeps their games.
+func HandleRegister(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	am, creds, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	guestID, err := upgradingGuest(r, creds.GuestToken)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, token, err := am.Register(creds.Username, creds.Password, guestID)
+	switch {
+	case errors.Is(err, accounts.ErrUsernameTaken), errors.Is(err, accounts.ErrGuestUpgraded):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, accounts.ErrInvalidUsername), errors.Is(err, accounts.ErrInvalidPassword):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("Error registering %q: %v", creds.Username, err)
+		writeError(w, http.StatusInternalServerError, "could not create the account")
+		return
+	}
+
+	if guestID != "" {
+		log.Printf("guest %s upgraded to account %s (%s)", guestID, account.ID, account.Username)
+		// The upgrade stays stored until analytics has it, see PublishAccountUpgraded
+		if err := am.PublishUpgrades(PublishAccountUpgraded); err != nil {
+			log.Printf("Upgrade of guest %s not published yet, will retry: %v", guestID, err)
+		}
+		// The session token identifies the player from now on
+		http.SetCookie(w, accounts.GuestCookieFor(""))
+	} else {
+		log.Printf("account %s registered as %s", account.ID, account.Username)
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newSessionResponse(account, token))
+}
+
+// PublishAccountUpgraded tells analytics that a guest became an account, so
+// it moves the guest's games and rating over. Upgrades that fail are
+// retried by accounts.Manager.StartUpgradePublisher.
+func PublishAccountUpgraded(account *accounts.Account) error {
+	return events.GetProducer().PublishAccountUpgraded(events.AccountUpgradedEvent{
+		GuestID:   account.GuestID,
+		AccountID: account.ID,
+		Username:  account.Username,
+	})
+}
+
+// HandleLogin serves POST /api/login
+func HandleLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	am, creds, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	account, token, err := am.Login(creds.Username, creds.Password)
+	if errors.Is(err, accounts.ErrInvalidCredentials) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error logging in %q: %v", creds.Username, err)
+		writeError(w, http.StatusInternalServerError, "could not log in")
+		return
+	}
+
+	json.NewEncoder(w).Encode(newSessionResponse(account, token))
+}
+
+// HandleLogout serves POST /api/logout, ending the session of the bearer token
+func HandleLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	am := accounts.GetManager()
+	if am == nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusServiceUnavailable, "accounts are unavailable")
+		return
+	}
+
+	if err := am.Logout(accounts.TokenFromRequest(r)); err != nil {
+		log.Printf("Error logging out: %v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleMe serves GET /api/me, the account of the bearer token
+func HandleMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	am := accounts.GetManager()
+	if am == nil {
+		writeError(w, http.StatusServiceUnavailable, "accounts are unavailable")
+		return
+	}
+
+	account, err := am.Authenticate(accounts.TokenFromRequest(r))
+	if errors.Is(err, accounts.ErrInvalidSession) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking session: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not check the session")
+		return
+	}
+
+	json.NewEncoder(w).Encode(newAccountResponse(account))
+}
+
+// HandlePreflight answers the CORS preflight browsers send before posting JSON
+// or an Authorization header to another origin
+func HandlePreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readCredentials decodes a username and password body, writing the error response if it cannot
+func readCredentials(w http.ResponseWriter, r *http.Request) (*accounts.Manager, credentialsRequest, bool) {
+	var creds credentialsRequest
+	am := accounts.GetManager()
+	if am == nil {
+		writeError(w, http.StatusServiceUnavailable, "accounts are unavailable")
+		return nil, creds, false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&creds); err != nil {
+		writeError(w, http.StatusBadRequest, "expected a JSON body with username and password")
+		return nil, creds, false
+	}
+	return am, creds, true
+}
+
+// upgradingGuest returns the guest a registration upgrades, empty if there is
+// none. A bad token in the body is an error, while a stale guest cookie is
+// ignored since the browser may hold one the client cannot clear.
+func upgradingGuest(r *http.Request, bodyToken string) (string, error) {
+	if bodyToken != "" {
+		return accounts.ParseGuestToken(bodyToken)
+	}
+	if cookie, err := r.Cookie(accounts.GuestCookie); err == nil {
+		if id, err := accounts.ParseGuestToken(cookie.Value); err == nil {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+func newAccountResponse(account *accounts.Account) AccountResponse {
+	return AccountResponse{ID: account.ID, Username: account.Username, GuestID: account.GuestID, CreatedAt: account.CreatedAt}
+}
+
+func newSessionResponse(account *accounts.Account, token string) SessionResponse {
+	return SessionResponse{Account: newAccountResponse(account), Token: token}
+}
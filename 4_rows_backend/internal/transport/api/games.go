@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+// GameResponse is an archived game as returned by GET /api/games/{id}
+type GameResponse struct {
+	ID          string       `json:"id"`
+	RoomCode    string       `json:"room_code"`
+	Variant     string       `json:"variant"`
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	Connect     int          `json:"connect"`
+	Player1Name string       `json:"player1_name"`
+	Player2Name string       `json:"player2_name"`
+	Player1ID   string       `json:"player1_id,omitempty"` // account or guest IDs
+	Player2ID   string       `json:"player2_id,omitempty"`
+	Winner      int          `json:"winner"`
+	IsDraw      bool         `json:"is_draw"`
+	Reason      string       `json:"reason"`
+	IsBotGame   bool         `json:"is_bot_game"`
+	BotLevel    string       `json:"bot_level,omitempty"`
+	Rated       bool         `json:"rated"`
+	Start       string       `json:"start_position,omitempty"` // seeded starting position, if any
+	Moves       []MoveRecord `json:"moves"`
+	Sequence    string       `json:"move_sequence"`
+	StartedAt   time.Time    `json:"started_at"`
+	EndedAt     time.Time    `json:"ended_at"`
+}
+
+type MoveRecord struct {
+	Action       string    `json:"action"`
+	Kept         bool      `json:"kept,omitempty"`
+	Column       int       `json:"column"`
+	Row          int       `json:"row"`
+	PlayerNumber int       `json:"player_number"`
+	PlayedAt     time.Time `json:"played_at"`
+}
+
+// HandleGetGame serves GET /api/games/{id}
+func HandleGetGame(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	record, err := game.GetRoomManager().GetGameRecord(r.PathValue("id"))
+	if errors.Is(err, game.ErrGameNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading game %s: %v", r.PathValue("id"), err)
+		writeError(w, http.StatusInternalServerError, "could not load the game")
+		return
+	}
+
+	response := GameResponse{
+		ID:          record.ID,
+		RoomCode:    record.RoomCode,
+		Variant:     string(record.Variant),
+		Width:       record.Dimensions.Cols,
+		Height:      record.Dimensions.Rows,
+		Connect:     record.Dimensions.Connect,
+		Player1Name: record.Players[0],
+		Player2Name: record.Players[1],
+		Player1ID:   record.Identity(1),
+		Player2ID:   record.Identity(2),
+		Winner:      record.Winner,
+		IsDraw:      record.Winner == 0,
+		Reason:      string(record.Reason),
+		IsBotGame:   record.IsBotGame,
+		BotLevel:    record.BotLevel,
+		Rated:       record.Rated,
+		Start:       record.Start,
+		Moves:       moveRecords(record.Moves),
+		Sequence:    game.FormatMoves(record.Moves),
+		StartedAt:   record.StartedAt,
+		EndedAt:     record.EndedAt,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func moveRecords(moves []game.Move) []MoveRecord {
+	records := make([]MoveRecord, len(moves))
+	for i, move := range moves {
+		records[i] = MoveRecord{
+			Action:       string(move.Kind),
+			Kept:         move.Kept,
+			Column:       move.Column,
+			Row:          move.Row,
+			PlayerNumber: move.PlayerNum,
+			PlayedAt:     move.PlayedAt,
+		}
+	}
+	return records
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
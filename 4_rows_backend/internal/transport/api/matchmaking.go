@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"4_rows_backend/internal/game"
+)
+
+// QueueStatsResponse describes one matchmaking queue as returned by GET /api/matchmaking
+type QueueStatsResponse struct {
+	Queue          string  `json:"queue"`
+	Waiting        int     `json:"waiting"`
+	Matches        int     `json:"matches"`
+	Fallbacks      int     `json:"bot_fallbacks"`
+	AvgWaitSeconds float64 `json:"avg_wait_seconds"`
+	AvgRatingGap   float64 `json:"avg_rating_gap"`
+}
+
+// HandleMatchmakingStats serves GET /api/matchmaking, the wait times and rating gaps of every queue
+func HandleMatchmakingStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	stats := game.GetMatchmaker().Stats()
+	response := make([]QueueStatsResponse, len(stats))
+	for i, s := range stats {
+		response[i] = QueueStatsResponse{
+			Queue:          s.Queue,
+			Waiting:        s.Waiting,
+			Matches:        s.Matches,
+			Fallbacks:      s.Fallbacks,
+			AvgWaitSeconds: s.AvgWait.Seconds(),
+			AvgRatingGap:   s.AvgRatingGap,
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string][]QueueStatsResponse{"queues": response})
+}
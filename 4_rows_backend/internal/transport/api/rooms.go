@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+// RoomResponse is an open public room as returned by GET /api/rooms
+type RoomResponse struct {
+	RoomCode    string               `json:"room_code"`
+	HostName    string               `json:"host_name"`
+	Variant     string               `json:"variant"`
+	Width       int                  `json:"width"`
+	Height      int                  `json:"height"`
+	Connect     int                  `json:"connect"`
+	Rated       bool                 `json:"rated"`
+	TimeControl *TimeControlResponse `json:"time_control,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// RoomMovesResponse is the move list of a room as returned by GET /api/rooms/{code}/moves
+type RoomMovesResponse struct {
+	RoomCode string       `json:"room_code"`
+	Moves    []MoveRecord `json:"moves"`
+	Sequence string       `json:"move_sequence"`
+}
+
+type TimeControlResponse struct {
+	InitialSeconds   int `json:"initial_seconds,omitempty"`
+	IncrementSeconds int `json:"increment_seconds,omitempty"`
+	PerMoveSeconds   int `json:"per_move_seconds,omitempty"`
+}
+
+// HandleListRooms serves GET /api/rooms, the public rooms waiting for an opponent
+func HandleListRooms(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	rooms := game.GetRoomManager().OpenRooms()
+	response := make([]RoomResponse, len(rooms))
+	for i, room := range rooms {
+		response[i] = RoomResponse{
+			RoomCode:  room.Code,
+			HostName:  room.Host,
+			Variant:   string(room.Variant),
+			Width:     room.Dimensions.Cols,
+			Height:    room.Dimensions.Rows,
+			Connect:   room.Dimensions.Connect,
+			Rated:     room.Rated,
+			CreatedAt: room.CreatedAt,
+		}
+		if tc := room.TimeControl; tc.Enabled() {
+			response[i].TimeControl = &TimeControlResponse{
+				InitialSeconds:   int(tc.Initial / time.Second),
+				IncrementSeconds: int(tc.Increment / time.Second),
+				PerMoveSeconds:   int(tc.PerMove / time.Second),
+			}
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string][]RoomResponse{"rooms": response})
+}
+
+// HandleGetRoomMoves serves GET /api/rooms/{code}/moves, the moves played so
+// far in a room's current game
+func HandleGetRoomMoves(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	code := r.PathValue("code")
+	moves, err := game.GetRoomManager().GetMoves(code)
+	if errors.Is(err, game.ErrRoomNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading moves of room %s: %v", code, err)
+		writeError(w, http.StatusInternalServerError, "could not load the moves")
+		return
+	}
+
+	json.NewEncoder(w).Encode(RoomMovesResponse{
+		RoomCode: code,
+		Moves:    moveRecords(moves),
+		Sequence: game.FormatMoves(moves),
+	})
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"time"
+
+	"4_rows_backend/internal/bot"
+	"4_rows_backend/internal/game"
+)
+
+const (
+	// AnalysisCooldown is the minimum time between two engine analyses in the same room
+	AnalysisCooldown = 5 * time.Second
+
+	hintBudget     = 1 * time.Second
+	analysisBudget = 2 * time.Second
+)
+
+// handleAnalysis runs the engine on the room's current position. A hint only
+// reveals the best column to the player to move; a full analysis returns the
+// score of every column.
+func (c *Client) handleAnalysis(full bool) {
+	roomCode := c.GetRoomCode()
+	if roomCode == "" {
+		c.SendJSON(NewError("not_in_room", "you are not in a room"))
+		return
+	}
+
+	rm := game.GetRoomManager()
+	room := rm.GetRoom(roomCode)
+	if room == nil {
+		c.SendJSON(NewError("room_gone", "room no longer exists"))
+		return
+	}
+
+	playerNum := rm.GetPlayerNumber(room, c.ID)
+	if playerNum == 0 {
+		c.SendJSON(NewError("not_player", "you are not a player in this room"))
+		return
+	}
+
+	if !room.AnalysisAllowed() {
+		c.SendJSON(NewError("analysis_disabled", "analysis is not available in this game"))
+		return
+	}
+
+	state := room.State()
+	if !state.GameStarted || state.GameOver {
+		c.SendJSON(NewError("no_position", "there is no position to analyze"))
+		return
+	}
+
+	if !full && state.CurrentTurn != playerNum {
+		c.SendJSON(NewError("not_your_turn", "hints are only available on your turn"))
+		return
+	}
+
+	if !room.TryStartAnalysis(AnalysisCooldown) {
+		c.SendJSON(NewError("rate_limited", "please wait before requesting another analysis"))
+		return
+	}
+
+	board, ruleState, _ := room.CopyPosition()
+	rules := room.Rules()
+	go func() {
+		if full {
+			analysis := bot.Analyze(&board, &ruleState, rules, state.CurrentTurn, analysisBudget)
+			c.Hub.SendToClient(c, NewMessage(TypeAnalysis, newAnalysisPayload(state.CurrentTurn, analysis)))
+			return
+		}
+
+		analysis := bot.Analyze(&board, &ruleState, rules, playerNum, hintBudget)
+		hint := HintPayload{Action: string(analysis.BestAction), Column: analysis.BestMove}
+		if analysis.BestMove >= 0 {
+			moves := analysis.Columns
+			if analysis.BestAction == game.MovePop {
+				moves = analysis.Pops
+			}
+			hint.Outcome = string(moves[analysis.BestMove].Outcome)
+		}
+		c.Hub.SendToClient(c, NewMessage(TypeHint, hint))
+	}()
+}
+
+func newAnalysisPayload(playerNum int, analysis bot.Analysis) AnalysisPayload {
+	return AnalysisPayload{
+		PlayerNumber: playerNum,
+		BestMove:     analysis.BestMove,
+		BestAction:   string(analysis.BestAction),
+		Exact:        analysis.Exact,
+		Columns:      columnScores(analysis.Columns),
+		Pops:         columnScores(analysis.Pops),
+	}
+}
+
+func columnScores(columns []bot.ColumnAnalysis) []ColumnScore {
+	if columns == nil {
+		return nil
+	}
+
+	scores := make([]ColumnScore, len(columns))
+	for i, col := range columns {
+		scores[i] = ColumnScore{
+			Column:    col.Column,
+			Playable:  col.Playable,
+			Outcome:   string(col.Outcome),
+			Score:     col.Score,
+			MovesLeft: col.MovesLeft,
+		}
+	}
+	return scores
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"errors"
+	"time"
+
+	"4_rows_backend/internal/chat"
+	"4_rows_backend/internal/game"
+)
+
+// chatError reports a chat message or emote that was not sent
+func chatError(err error) OutgoingMessage {
+	switch {
+	case errors.Is(err, chat.ErrRateLimited):
+		return NewError("rate_limited", err.Error())
+	case errors.Is(err, chat.ErrUnknownEmote):
+		return NewError("unknown_emote", err.Error())
+	}
+	return NewError("invalid_chat", err.Error())
+}
+
+func newChatPayload(msg chat.Message) ChatPayload {
+	return ChatPayload{
+		Kind:         string(msg.Kind),
+		Channel:      string(msg.Channel),
+		Sender:       msg.Sender,
+		PlayerNumber: msg.PlayerNumber,
+		Text:         msg.Text,
+		SentAt:       msg.SentAt,
+	}
+}
+
+// chatHistory returns the recent chat of a room as a client may see it
+func chatHistory(roomCode string, spectator bool) []ChatPayload {
+	history := chat.GetManager().History(roomCode, spectator)
+	payloads := make([]ChatPayload, len(history))
+	for i, msg := range history {
+		payloads[i] = newChatPayload(msg)
+	}
+	return payloads
+}
+
+// handleChat sends a chat message or emote to the client's room. Spectators
+// talk only to each other when the spectator channel is enabled.
+func (c *Client) handleChat(kind chat.Kind, text string) {
+	roomCode := c.GetRoomCode()
+	if roomCode == "" {
+		c.SendJSON(NewError("not_in_room", "you are not in a room"))
+		return
+	}
+
+	rm := game.GetRoomManager()
+	room := rm.GetRoom(roomCode)
+	if room == nil {
+		c.SendJSON(NewError("room_gone", "room no longer exists"))
+		return
+	}
+
+	cm := chat.GetManager()
+	text, err := cm.Compose(kind, text)
+	if err != nil {
+		c.SendJSON(chatError(err))
+		return
+	}
+	if !c.limiter.Allow() {
+		c.SendJSON(chatError(chat.ErrRateLimited))
+		return
+	}
+
+	msg := chat.Message{Kind: kind, Channel: chat.ChannelRoom, Sender: "Spectator", Text: text, SentAt: time.Now()}
+	spectator := c.IsSpectator()
+	if spectator && cm.SpectatorChannel() {
+		msg.Channel = chat.ChannelSpectators
+	}
+	if !spectator {
+		msg.PlayerNumber = rm.GetPlayerNumber(room, c.ID)
+		if msg.PlayerNumber != 0 {
+			msg.Sender = room.State().Players[msg.PlayerNumber-1].Name
+		}
+	}
+	cm.Record(room.Code, msg)
+
+	payload := newChatPayload(msg)
+	c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		if client != c && client.ChatMuted() {
+			return OutgoingMessage{}
+		}
+		if msg.Channel == chat.ChannelSpectators && !client.IsSpectator() {
+			return OutgoingMessage{}
+		}
+		return NewMessage(TypeChatMessage, payload)
+	})
+}
+
+// handleMuteChat turns delivery of other clients' chat and emotes off or on
+func (c *Client) handleMuteChat(muted bool) {
+	c.mu.Lock()
+	c.muted = muted
+	c.mu.Unlock()
+
+	c.SendJSON(NewMessage(TypeChatMuted, ChatMutedPayload{Muted: muted}))
+}
+
+// ChatMuted reports whether the client has muted chat from others
+func (c *Client) ChatMuted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.muted
+}
@@ -2,13 +2,17 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"sync"
 	"time"
 
+	"4_rows_backend/internal/accounts"
 	"4_rows_backend/internal/bot"
+	"4_rows_backend/internal/chat"
 	"4_rows_backend/internal/events"
 	"4_rows_backend/internal/game"
+	"4_rows_backend/internal/reconnect"
 
 	"github.com/gorilla/websocket"
 )
@@ -20,25 +24,40 @@ const (
 )
 
 type Client struct {
-	ID       string
-	Conn     *websocket.Conn
-	Send     chan []byte
-	Hub      *Hub
-	RoomCode string
-	mu       sync.Mutex
+	ID           string
+	Conn         *websocket.Conn
+	Send         chan []byte
+	Hub          *Hub
+	RoomCode     string
+	replay       *replaySession
+	watching     bool // RoomCode is a room the client spectates rather than plays in
+	limiter      *chat.Limiter
+	muted        bool               // chat from other clients is not delivered
+	account      *accounts.Account  // nil for guests
+	guestID      string             // stable ID of a guest, see accounts.NewGuest
+	closed       bool               // the read loop has ended; guarded by actions
+	pendingMatch *game.MatchRequest // find_match waiting for its rating lookup; guarded by actions
+	actions      sync.Mutex         // serializes handling messages with work done for the client elsewhere
+	mu           sync.Mutex
 }
 
 func NewClient(id string, conn *websocket.Conn, hub *Hub) *Client {
 	return &Client{
-		ID:   id,
-		Conn: conn,
-		Send: make(chan []byte, 256),
-		Hub:  hub,
+		ID:      id,
+		Conn:    conn,
+		Send:    make(chan []byte, 256),
+		Hub:     hub,
+		limiter: chat.GetManager().NewLimiter(),
 	}
 }
 
 func (c *Client) ReadLoop() {
 	defer func() {
+		c.actions.Lock()
+		c.closed = true
+		c.actions.Unlock()
+
+		c.stopReplay()
 		c.handleDisconnect()
 		c.Hub.Unregister(c)
 		c.Conn.Close()
@@ -60,7 +79,9 @@ func (c *Client) ReadLoop() {
 			return
 		}
 
+		c.actions.Lock()
 		c.handleMessage(rawMessage)
+		c.actions.Unlock()
 	}
 }
 
@@ -109,39 +130,188 @@ func (c *Client) handleMessage(rawMessage []byte) {
 		c.SendJSON(NewMessage(TypePong, nil))
 
 	case TypeCreateRoom:
-		c.handleCreateRoom(msg.PlayerName)
+		start, err := startPosition(msg)
+		if err != nil {
+			c.SendJSON(createRoomError(err))
+			return
+		}
+		c.handleCreateRoom(msg.PlayerName, game.RoomOptions{
+			Dimensions:  boardDimensions(msg),
+			Variant:     game.Variant(msg.Variant),
+			Rated:       msg.Rated,
+			Public:      msg.Public,
+			TimeControl: timeControl(msg),
+			Start:       start,
+		})
 
 	case TypeJoinRoom:
 		c.handleJoinRoom(msg.RoomCode, msg.PlayerName)
 
 	case TypeMove:
-		c.handleMove(msg.Column)
+		c.handleMove(game.MoveDrop, msg.Column)
+
+	case TypePop:
+		c.handleMove(game.MovePop, msg.Column)
 
 	case TypeRematchRequest:
 		c.handleRematch()
 
 	case TypeCreateBotGame:
-		c.handleCreateBotGame(msg.PlayerName)
+		start, err := startPosition(msg)
+		if err != nil {
+			c.SendJSON(createRoomError(err))
+			return
+		}
+		c.handleCreateBotGame(msg.PlayerName, msg.Difficulty, game.RoomOptions{
+			Dimensions:  boardDimensions(msg),
+			Variant:     game.Variant(msg.Variant),
+			BotFirst:    msg.BotFirst,
+			TimeControl: timeControl(msg),
+			Start:       start,
+		})
+
+	case TypeResume:
+		c.handleResume(msg.ResumeToken)
+
+	case TypeRequestState:
+		c.handleRequestState()
+
+	case TypeRequestHint:
+		c.handleAnalysis(false)
+
+	case TypeAnalyzePosition:
+		c.handleAnalysis(true)
+
+	case TypeResign:
+		c.handleResign()
+
+	case TypeOfferDraw:
+		c.handleOfferDraw()
+
+	case TypeAcceptDraw:
+		c.handleAnswerDraw(true)
+
+	case TypeDeclineDraw:
+		c.handleAnswerDraw(false)
+
+	case TypeRequestTakeback:
+		c.handleRequestTakeback()
+
+	case TypeAcceptTakeback:
+		c.handleAnswerTakeback(true)
+
+	case TypeDeclineTakeback:
+		c.handleAnswerTakeback(false)
+
+	case TypeWatchReplay:
+		c.handleWatchReplay(msg.GameID, msg.Speed)
+
+	case TypeReplaySpeed:
+		c.handleReplaySpeed(msg.Speed)
+
+	case TypeStopReplay:
+		c.stopReplay()
+
+	case TypeChat:
+		c.handleChat(chat.KindText, msg.Text)
+
+	case TypeEmote:
+		c.handleChat(chat.KindEmote, msg.Emote)
+
+	case TypeMuteChat:
+		c.handleMuteChat(msg.Muted)
+
+	case TypeFindMatch:
+		c.handleFindMatch(msg)
+
+	case TypeCancelMatch:
+		c.handleCancelMatch()
+
+	case TypeSubscribeLobby:
+		c.handleSubscribeLobby()
+
+	case TypeUnsubscribeLobby:
+		c.Hub.UnsubscribeLobby(c)
+
+	case TypeSpectateRoom:
+		c.handleSpectate(msg.RoomCode)
+
+	case TypeStopSpectating:
+		c.stopSpectating()
 
 	default:
 		c.SendJSON(NewError("unknown_type", "message type not recognized"))
 	}
 }
 
-func (c *Client) handleCreateRoom(playerName string) {
+// boardDimensions reads the requested board size, using the classic value for any field left out.
+// It returns the zero value if none was given, leaving the choice to the variant.
+func boardDimensions(msg IncomingMessage) game.Dimensions {
+	if msg.Width == 0 && msg.Height == 0 && msg.Connect == 0 {
+		return game.Dimensions{}
+	}
+
+	d := game.ClassicDimensions
+	if msg.Width != 0 {
+		d.Cols = msg.Width
+	}
+	if msg.Height != 0 {
+		d.Rows = msg.Height
+	}
+	if msg.Connect != 0 {
+		d.Connect = msg.Connect
+	}
+	return d
+}
+
+// startPosition reads the position a room should start from, nil for the variant's starting position
+func startPosition(msg IncomingMessage) (*game.Position, error) {
+	if msg.Position == "" {
+		return nil, nil
+	}
+	pos, err := game.ParsePosition(msg.Position)
+	if err != nil {
+		return nil, err
+	}
+	return &pos, nil
+}
+
+// createRoomError reports options a room could not be created with
+func createRoomError(err error) OutgoingMessage {
+	if errors.Is(err, game.ErrUnknownVariant) {
+		return NewError("invalid_variant", err.Error())
+	}
+	if errors.Is(err, game.ErrInvalidTimeControl) {
+		return NewError("invalid_time_control", err.Error())
+	}
+	if errors.Is(err, game.ErrInvalidNotation) {
+		return NewError("invalid_position", err.Error())
+	}
+	return NewError("invalid_dimensions", err.Error())
+}
+
+func (c *Client) handleCreateRoom(playerName string, opts game.RoomOptions) {
+	c.stopSpectating()
+
 	rm := game.GetRoomManager()
 	if playerName == "" {
 		playerName = "Player 1"
 	}
-	room := rm.CreateRoom(c.ID, playerName)
+	player := c.player(playerName)
+	room, err := rm.CreateRoom(player, opts)
+	if err != nil {
+		c.SendJSON(createRoomError(err))
+		return
+	}
 
 	c.SetRoomCode(room.Code)
 	c.Hub.JoinRoom(room.Code, c)
 
-	log.Printf("client %s (%s) created room %s", c.ID, playerName, room.Code)
+	log.Printf("client %s (%s) created room %s", c.ID, player.Name, room.Code)
 
 	c.SendJSON(NewMessage(TypeRoomCreated, RoomCreatedPayload{
-		RoomCode: room.Code,
+		RoomCode:    room.Code,
+		ResumeToken: reconnect.GetManager().IssueToken(room.Code, 1, c.ID),
 	}))
 }
 
@@ -150,12 +320,14 @@ func (c *Client) handleJoinRoom(code string, playerName string) {
 		c.SendJSON(NewError("missing_code", "room code is required"))
 		return
 	}
+	c.stopSpectating()
 
 	rm := game.GetRoomManager()
 	if playerName == "" {
 		playerName = "Player 2"
 	}
-	room, err := rm.JoinRoom(code, c.ID, playerName)
+	player := c.player(playerName)
+	room, err := rm.JoinRoom(code, player)
 
 	if err != nil {
 		c.SendJSON(NewError("join_failed", err.Error()))
@@ -165,7 +337,7 @@ func (c *Client) handleJoinRoom(code string, playerName string) {
 	c.SetRoomCode(code)
 	c.Hub.JoinRoom(code, c)
 
-	log.Printf("client %s (%s) joined room %s", c.ID, playerName, code)
+	log.Printf("client %s (%s) joined room %s", c.ID, player.Name, code)
 
 	c.SendJSON(NewMessage(TypeRoomJoined, RoomJoinedPayload{
 		RoomCode: code,
@@ -173,24 +345,51 @@ func (c *Client) handleJoinRoom(code string, playerName string) {
 	}))
 
 	if room.GameStarted {
-		c.Hub.BroadcastToRoom(code, func(client *Client) OutgoingMessage {
-			playerNum := rm.GetPlayerNumber(room, client.ID)
-			return NewMessage(TypeGameStart, GameStartPayload{
-				RoomCode:     code,
-				PlayerNumber: playerNum,
-				Player1Name:  room.Players[0].Name,
-				Player2Name:  room.Players[1].Name,
-			})
-		})
+		broadcastGameStart(c.Hub, room)
 	}
+
+	c.sendStateSync(room)
+}
+
+// broadcastGameStart tells everyone in a room that both players are in, giving
+// each player their number and resume token
+func broadcastGameStart(hub *Hub, room *game.Room) {
+	rm := game.GetRoomManager()
+	dims := room.Dimensions()
+	timeControl := newTimeControlPayload(room.TimeControl())
+	hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		playerNum := rm.GetPlayerNumber(room, client.ID)
+		resumeToken := ""
+		if playerNum != 0 {
+			resumeToken = reconnect.GetManager().IssueToken(room.Code, playerNum, client.ID)
+		}
+		return NewMessage(TypeGameStart, GameStartPayload{
+			RoomCode:      room.Code,
+			PlayerNumber:  playerNum,
+			Player1Name:   room.Players[0].Name,
+			Player2Name:   room.Players[1].Name,
+			ResumeToken:   resumeToken,
+			Rated:         room.Rated,
+			Width:         dims.Cols,
+			Height:        dims.Rows,
+			Connect:       dims.Connect,
+			Variant:       string(room.Variant),
+			StartPosition: room.StartPosition(),
+			TimeControl:   timeControl,
+		})
+	})
 }
 
-func (c *Client) handleMove(column int) {
+func (c *Client) handleMove(kind game.MoveKind, column int) {
 	roomCode := c.GetRoomCode()
 	if roomCode == "" {
 		c.SendJSON(NewError("not_in_room", "you are not in a room"))
 		return
 	}
+	if c.IsSpectator() {
+		c.SendJSON(NewError("spectator", "spectators cannot play moves"))
+		return
+	}
 
 	rm := game.GetRoomManager()
 	room := rm.GetRoom(roomCode)
@@ -200,88 +399,109 @@ func (c *Client) handleMove(column int) {
 	}
 
 	playerNum := rm.GetPlayerNumber(room, c.ID)
-	row, err := room.MakeMove(column, playerNum)
+	result, err := room.Play(game.Move{Kind: kind, Column: column, PlayerNum: playerNum})
 
+	if errors.Is(err, game.ErrTimeUp) {
+		c.SendJSON(NewError("time_up", "your time has run out"))
+		return
+	}
 	if err != nil {
 		c.SendJSON(NewError("invalid_move", "move not allowed"))
 		return
 	}
 
-	moveResult := MoveResultPayload{
-		Column:       column,
-		Row:          row,
-		PlayerNumber: playerNum,
-		NextPlayer:   room.CurrentTurn,
-		Valid:        true,
-	}
+	rm.SaveRoomState(room)
+	broadcastMoveResult(c.Hub, room, result)
 
-	c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-		return NewMessage(TypeMoveResult, moveResult)
-	})
+	if result.GameOver {
+		publishGameCompleted(room)
+		return
+	}
 
-	won, cells := room.Board.CheckWin(row, column, playerNum)
-	if won {
-		winCells := make([]CellPosition, len(cells))
-		for i, cell := range cells {
-			winCells[i] = CellPosition{Row: cell.Row, Col: cell.Col}
-		}
+	// If it's a bot game and now it's the bot's turn, make the bot move
+	if room.IsBotGame && result.NextPlayer == 2 {
+		go c.makeBotMove(room)
+	}
+}
 
-		c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-			return NewMessage(TypeGameOver, GameOverPayload{
-				Winner:       playerNum,
-				WinningCells: winCells,
-				IsDraw:       false,
-			})
+// publishGameCompleted sends a finished game to Kafka for analytics
+func publishGameCompleted(room *game.Room) {
+	if producer := events.GetProducer(); producer != nil {
+		state := room.State()
+		producer.PublishGameCompleted(events.GameCompletedEvent{
+			RoomCode:        room.Code,
+			Player1Name:     state.Players[0].Name,
+			Player2Name:     state.Players[1].Name,
+			Player1ID:       state.Players[0].Identity(),
+			Player2ID:       state.Players[1].Identity(),
+			Winner:          state.Winner,
+			Reason:          string(state.EndReason),
+			IsBotGame:       room.IsBotGame,
+			Rated:           room.Rated,
+			DurationSeconds: 0, // TODO: Track actual duration
 		})
-
-		// Publish game completed event to Kafka
-		if producer := events.GetProducer(); producer != nil {
-			producer.PublishGameCompleted(events.GameCompletedEvent{
-				RoomCode:        roomCode,
-				Player1Name:     room.Players[0].Name,
-				Player2Name:     room.Players[1].Name,
-				Winner:          playerNum,
-				IsBotGame:       room.IsBotGame,
-				DurationSeconds: 0, // TODO: Track actual duration
-			})
-		}
-		return
 	}
+}
 
-	if room.Board.IsDraw() {
-		c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-			return NewMessage(TypeGameOver, GameOverPayload{
-				Winner: 0,
-				IsDraw: true,
-			})
-		})
+func newMoveResultPayload(move game.Move, nextPlayer int) MoveResultPayload {
+	return MoveResultPayload{
+		Action:       string(move.Kind),
+		Kept:         move.Kept,
+		Column:       move.Column,
+		Row:          move.Row,
+		PlayerNumber: move.PlayerNum,
+		NextPlayer:   nextPlayer,
+		Valid:        true,
+	}
+}
 
-		// Publish draw event to Kafka
-		if producer := events.GetProducer(); producer != nil {
-			producer.PublishGameCompleted(events.GameCompletedEvent{
-				RoomCode:        roomCode,
-				Player1Name:     room.Players[0].Name,
-				Player2Name:     room.Players[1].Name,
-				Winner:          0,
-				IsBotGame:       room.IsBotGame,
-				DurationSeconds: 0,
-			})
-		}
-		return
+func newGameOverPayload(gameID string, winner int, reason game.EndReason, winningCells []game.CellPos) GameOverPayload {
+	gameOver := GameOverPayload{
+		GameID: gameID,
+		Winner: winner,
+		IsDraw: winner == 0,
+		Reason: string(reason),
+	}
+	for _, cell := range winningCells {
+		gameOver.WinningCells = append(gameOver.WinningCells, CellPosition{Row: cell.Row, Col: cell.Col})
 	}
+	return gameOver
+}
 
-	// If it's a bot game and now it's the bot's turn, make the bot move
-	if room.IsBotGame && room.CurrentTurn == 2 {
-		go c.makeBotMove(room)
+// broadcastMoveResult tells the room about a move and, if it ended the game, the result
+func broadcastMoveResult(hub *Hub, room *game.Room, result game.MoveResult) {
+	moveResult := newMoveResultPayload(result.Move, result.NextPlayer)
+	moveResult.Clock = newClockPayload(result.Clock)
+
+	hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeMoveResult, moveResult)
+	})
+
+	if result.GameOver {
+		broadcastGameOver(hub, room)
 	}
 }
 
+// broadcastGameOver tells the room who won and why the game ended
+func broadcastGameOver(hub *Hub, room *game.Room) {
+	state := room.State()
+	gameOver := newGameOverPayload(state.GameID, state.Winner, state.EndReason, state.WinningCells)
+
+	hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeGameOver, gameOver)
+	})
+}
+
 func (c *Client) handleRematch() {
 	roomCode := c.GetRoomCode()
 	if roomCode == "" {
 		c.SendJSON(NewError("not_in_room", "you are not in a room"))
 		return
 	}
+	if c.IsSpectator() {
+		c.SendJSON(NewError("spectator", "spectators cannot request a rematch"))
+		return
+	}
 
 	rm := game.GetRoomManager()
 	room := rm.GetRoom(roomCode)
@@ -311,6 +531,11 @@ func (c *Client) handleRematch() {
 		c.SendJSON(NewMessage(TypeRematchAccepted, RematchAcceptedPayload{
 			Message: "Starting new game against bot...",
 		}))
+
+		// A seeded position may have the bot to move
+		if room.State().CurrentTurn == 2 {
+			go c.makeBotMove(room)
+		}
 		return
 	}
 
@@ -335,6 +560,9 @@ func (c *Client) handleRematch() {
 
 		c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
 			clientPlayerNum := rm.GetPlayerNumber(room, client.ID)
+			if clientPlayerNum == 0 {
+				return OutgoingMessage{}
+			}
 			if clientPlayerNum == playerNum {
 				// The player who just requested
 				return NewMessage(TypeRematchWaiting, RematchWaitingPayload{
@@ -352,20 +580,239 @@ func (c *Client) handleRematch() {
 	}
 }
 
+func (c *Client) handleResume(token string) {
+	if token == "" {
+		c.SendJSON(NewError("missing_token", "resume token is required"))
+		return
+	}
+
+	c.stopSpectating()
+	if c.GetRoomCode() != "" {
+		c.SendJSON(NewError("already_in_room", "you are already in a room"))
+		return
+	}
+
+	rc := reconnect.GetManager()
+	claims, err := rc.ParseToken(token)
+	if err != nil {
+		c.SendJSON(NewError("resume_failed", err.Error()))
+		return
+	}
+
+	rm := game.GetRoomManager()
+
+	// A registered player's seat can only be resumed while logged in as them
+	if room := rm.GetRoom(claims.RoomCode); room != nil {
+		if account := room.Player(claims.PlayerNum).AccountID; account != "" && account != c.AccountID() {
+			c.SendJSON(NewError("resume_failed", game.ErrSlotTaken.Error()))
+			return
+		}
+	}
+
+	room, err := rm.ReconnectPlayer(claims.RoomCode, claims.PlayerNum, claims.PlayerID, c.ID)
+	if err != nil {
+		c.SendJSON(NewError("resume_failed", err.Error()))
+		return
+	}
+	rc.Resume(room.Code, claims.PlayerNum)
+
+	// Detach the previous connection if it is still hanging around
+	for _, client := range c.Hub.GetRoomClients(room.Code) {
+		if client.ID == claims.PlayerID {
+			c.Hub.LeaveRoom(room.Code, client)
+			client.SetRoomCode("")
+		}
+	}
+
+	c.SetRoomCode(room.Code)
+	c.Hub.JoinRoom(room.Code, c)
+
+	log.Printf("client %s resumed room %s as player %d", c.ID, room.Code, claims.PlayerNum)
+
+	c.SendJSON(NewMessage(TypeResumed, ResumedPayload{
+		RoomCode:     room.Code,
+		PlayerNumber: claims.PlayerNum,
+		Player1Name:  room.Player(1).Name,
+		Player2Name:  room.Player(2).Name,
+		ResumeToken:  rc.IssueToken(room.Code, claims.PlayerNum, c.ID),
+	}))
+
+	c.sendStateSync(room)
+
+	c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		if client != c {
+			return NewMessage(TypeOpponentReconnected, nil)
+		}
+		return OutgoingMessage{}
+	})
+
+	// A room restored after a restart may be waiting on a bot move that was never made
+	if state := room.State(); room.IsBotGame && state.CurrentTurn == 2 && !state.GameOver {
+		go c.makeBotMove(room)
+	}
+}
+
+func (c *Client) handleRequestState() {
+	roomCode := c.GetRoomCode()
+	if roomCode == "" {
+		c.SendJSON(NewError("not_in_room", "you are not in a room"))
+		return
+	}
+
+	room := game.GetRoomManager().GetRoom(roomCode)
+	if room == nil {
+		c.SendJSON(NewError("room_gone", "room no longer exists"))
+		return
+	}
+
+	c.sendStateSync(room)
+}
+
+func newMoveRecord(move game.Move) MoveRecord {
+	return MoveRecord{
+		Action:       string(move.Kind),
+		Kept:         move.Kept,
+		Column:       move.Column,
+		Row:          move.Row,
+		PlayerNumber: move.PlayerNum,
+		PlayedAt:     move.PlayedAt,
+	}
+}
+
+// sendStateSync sends a full snapshot of the room so the client can rebuild its view
+func (c *Client) sendStateSync(room *game.Room) {
+	state := room.State()
+
+	moves := make([]MoveRecord, len(state.Moves))
+	for i, move := range state.Moves {
+		moves[i] = newMoveRecord(move)
+	}
+
+	winCells := make([]CellPosition, len(state.WinningCells))
+	for i, cell := range state.WinningCells {
+		winCells[i] = CellPosition{Row: cell.Row, Col: cell.Col}
+	}
+
+	c.SendJSON(NewMessage(TypeStateSync, StateSyncPayload{
+		RoomCode:        room.Code,
+		GameID:          state.GameID,
+		PlayerNumber:    game.GetRoomManager().GetPlayerNumber(room, c.ID),
+		Board:           state.Board,
+		Width:           state.Dimensions.Cols,
+		Height:          state.Dimensions.Rows,
+		Connect:         state.Dimensions.Connect,
+		Variant:         string(state.Variant),
+		Kept:            state.Kept,
+		CurrentTurn:     state.CurrentTurn,
+		Player1Name:     state.Players[0].Name,
+		Player2Name:     state.Players[1].Name,
+		Moves:           moves,
+		MoveSequence:    game.FormatMoves(state.Moves),
+		Position:        room.Position().String(),
+		GameStarted:     state.GameStarted,
+		GameOver:        state.GameOver,
+		Winner:          state.Winner,
+		IsDraw:          state.GameOver && state.Winner == 0,
+		WinningCells:    winCells,
+		RematchRequests: state.RematchRequests,
+		Reason:          string(state.EndReason),
+		DrawOfferedBy:   state.DrawOfferedBy,
+		TakebackBy:      state.TakebackBy,
+		Spectators:      c.Hub.SpectatorCount(room.Code),
+		Chat:            chatHistory(room.Code, c.IsSpectator()),
+		TimeControl:     newTimeControlPayload(state.TimeControl),
+		Clock:           newClockPayload(state.Clock),
+	}))
+}
+
 func (c *Client) handleDisconnect() {
+	game.GetMatchmaker().Cancel(c.ID)
+
+	if c.IsSpectator() {
+		c.stopSpectating()
+		return
+	}
+
 	roomCode := c.GetRoomCode()
 	if roomCode == "" {
 		return
 	}
 
+	rm := game.GetRoomManager()
+	room := rm.GetRoom(roomCode)
+	if room == nil {
+		return
+	}
+
+	playerNum := rm.GetPlayerNumber(room, c.ID)
+	if playerNum == 0 {
+		return
+	}
+
+	// Hold the slot so the player can resume from a new connection
+	rm.DisconnectPlayer(room, playerNum)
+
+	rc := reconnect.GetManager()
 	c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-		if client.ID != c.ID {
-			return NewMessage(TypeOpponentLeft, nil)
+		if client != c {
+			return NewMessage(TypeOpponentDisconnected, OpponentDisconnectedPayload{
+				GraceSeconds: int(rc.GracePeriod().Seconds()),
+			})
 		}
 		return OutgoingMessage{}
 	})
 
+	log.Printf("room %s: player %d disconnected, holding slot for %s", roomCode, playerNum, rc.GracePeriod())
+
+	hub := c.Hub
+	rc.Hold(roomCode, playerNum, func() {
+		expireHeldSlot(hub, roomCode, playerNum)
+	})
+}
+
+// HoldRestoredRooms starts grace timers for the players of rooms restored from storage,
+// so rooms nobody comes back to are removed like any other abandoned room
+func HoldRestoredRooms(rooms []*game.Room) {
+	hub := GetHub()
+	rc := reconnect.GetManager()
+
+	for _, room := range rooms {
+		for i, slot := range room.Players {
+			if slot.ID == "" || room.IsConnected(i+1) {
+				continue
+			}
+
+			roomCode, playerNum := room.Code, i+1
+			rc.Hold(roomCode, playerNum, func() {
+				expireHeldSlot(hub, roomCode, playerNum)
+			})
+		}
+	}
+}
+
+// expireHeldSlot removes a room once a disconnected player's grace period runs out
+func expireHeldSlot(hub *Hub, roomCode string, playerNum int) {
 	rm := game.GetRoomManager()
+	room := rm.GetRoom(roomCode)
+	if room == nil || room.IsConnected(playerNum) {
+		return
+	}
+
+	log.Printf("room %s: player %d did not reconnect, removing room", roomCode, playerNum)
+
+	// A game still in progress goes to the player who stayed
+	if room.Abandon(playerNum) {
+		rm.SaveRoomState(room)
+		broadcastGameOver(hub, room)
+		publishGameCompleted(room)
+	}
+
+	hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeOpponentLeft, nil)
+	})
+
+	reconnect.GetManager().Release(roomCode)
+	chat.GetManager().Clear(roomCode)
 	rm.RemoveRoom(roomCode)
 }
 
@@ -399,97 +846,148 @@ func (c *Client) GetRoomCode() string {
 	return c.RoomCode
 }
 
-func (c *Client) handleCreateBotGame(playerName string) {
+// AccountID returns the client's account ID, empty for guests
+func (c *Client) AccountID() string {
+	if c.account == nil {
+		return ""
+	}
+	return c.account.ID
+}
+
+// player is who the client takes a seat as. Registered players always play
+// under their username.
+func (c *Client) player(name string) game.Player {
+	if c.account != nil {
+		return game.Player{ID: c.ID, Name: c.account.Username, AccountID: c.account.ID}
+	}
+	return game.Player{ID: c.ID, Name: name, GuestID: c.guestID}
+}
+
+func (c *Client) handleCreateBotGame(playerName string, difficultyName string, opts game.RoomOptions) {
+	difficulty, err := bot.ParseDifficulty(difficultyName)
+	if err != nil {
+		c.SendJSON(NewError("invalid_difficulty", err.Error()))
+		return
+	}
+
+	c.stopSpectating()
+
 	rm := game.GetRoomManager()
 	if playerName == "" {
 		playerName = "Player 1"
 	}
-	room := rm.CreateBotRoom(c.ID, playerName)
+	opts.BotLevel = string(difficulty)
+	player := c.player(playerName)
+	room, err := rm.CreateBotRoom(player, opts)
+	if err != nil {
+		c.SendJSON(createRoomError(err))
+		return
+	}
+	dims := room.Dimensions()
 
 	c.SetRoomCode(room.Code)
 	c.Hub.JoinRoom(room.Code, c)
 
-	log.Printf("client %s (%s) created bot game %s", c.ID, playerName, room.Code)
+	log.Printf("client %s (%s) created %s bot game %s", c.ID, player.Name, difficulty, room.Code)
+
+	resumeToken := reconnect.GetManager().IssueToken(room.Code, 1, c.ID)
 
 	// Send room created message
 	c.SendJSON(NewMessage(TypeRoomCreated, RoomCreatedPayload{
-		RoomCode: room.Code,
+		RoomCode:    room.Code,
+		ResumeToken: resumeToken,
 	}))
 
 	// Send game start message (player is always player 1 in bot games)
 	c.SendJSON(NewMessage(TypeGameStart, GameStartPayload{
-		RoomCode:     room.Code,
-		PlayerNumber: 1,
-		Player1Name:  room.Players[0].Name,
-		Player2Name:  room.Players[1].Name,
+		RoomCode:      room.Code,
+		PlayerNumber:  1,
+		Player1Name:   room.Players[0].Name,
+		Player2Name:   room.Players[1].Name,
+		ResumeToken:   resumeToken,
+		BotDifficulty: string(difficulty),
+		Width:         dims.Cols,
+		Height:        dims.Rows,
+		Connect:       dims.Connect,
+		Variant:       string(room.Variant),
+		StartPosition: room.StartPosition(),
+		TimeControl:   newTimeControlPayload(room.TimeControl()),
 	}))
+
+	// The bot may move first, or a seeded position may have it to move
+	if room.State().CurrentTurn == 2 {
+		go c.makeBotMove(room)
+	}
 }
 
-func (c *Client) makeBotMove(room *game.Room) {
-	// Add a small delay to make the bot feel more natural
-	time.Sleep(500 * time.Millisecond)
+// botThinkingLimit is how long the bot may spend on a move in a timed game,
+// zero in untimed ones. A per-move clock is used up to half; a game budget
+// has to last, so the bot spends a small share of it plus half the increment.
+func botThinkingLimit(state game.GameState) time.Duration {
+	if state.Clock == nil {
+		return 0
+	}
+	left := state.Clock.Remaining[bot.BotPlayerNumber-1]
+	if state.TimeControl.PerMove > 0 {
+		return max(left/2, time.Millisecond)
+	}
+	return max(min(left/2, left/20+state.TimeControl.Increment/2), time.Millisecond)
+}
 
-	roomCode := room.Code
+func (c *Client) makeBotMove(room *game.Room) {
+	started := time.Now()
 
 	// Check if the game is still valid
-	if room.GameOver {
+	state := room.State()
+	if state.GameOver {
 		return
 	}
 
-	// Create the bot and get the best move
-	gameBot := bot.NewBot()
+	// Create the bot and search on a snapshot of the board
+	difficulty, err := bot.ParseDifficulty(room.BotLevel)
+	if err != nil {
+		difficulty = bot.DefaultDifficulty
+	}
+	gameBot := bot.NewBotWithDifficulty(difficulty)
+	limit := botThinkingLimit(state)
+	gameBot.SetTimeLimit(limit)
+	board, ruleState, version := room.CopyPosition()
 	humanPlayer := 1 // Human is always player 1 in bot games
-	botColumn := gameBot.GetBestMove(&room.Board, humanPlayer)
+	botMove := gameBot.ChooseMove(&board, &ruleState, room.Rules(), humanPlayer)
 
-	if botColumn < 0 {
+	if botMove.Column < 0 {
 		return
 	}
 
-	// Make the bot's move
-	row, err := room.MakeMove(botColumn, 2)
+	// Add a small delay to make the bot feel more natural, unless its clock is short
+	delay := 500 * time.Millisecond
+	if limit > 0 {
+		delay = min(delay, limit)
+	}
+	if elapsed := time.Since(started); elapsed < delay {
+		time.Sleep(delay - elapsed)
+	}
+
+	// Make the bot's move, unless a takeback or rematch replaced the position it was chosen on
+	result, err := room.PlayFrom(version, botMove)
+	if errors.Is(err, game.ErrPositionChanged) {
+		return
+	}
 	if err != nil {
 		log.Printf("bot move error: %v", err)
 		return
 	}
 
-	// Broadcast the bot's move result
-	moveResult := MoveResultPayload{
-		Column:       botColumn,
-		Row:          row,
-		PlayerNumber: 2,
-		NextPlayer:   room.CurrentTurn,
-		Valid:        true,
-	}
-
-	c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-		return NewMessage(TypeMoveResult, moveResult)
-	})
-
-	// Check for bot win
-	won, cells := room.Board.CheckWin(row, botColumn, 2)
-	if won {
-		winCells := make([]CellPosition, len(cells))
-		for i, cell := range cells {
-			winCells[i] = CellPosition{Row: cell.Row, Col: cell.Col}
-		}
+	game.GetRoomManager().SaveRoomState(room)
+	broadcastMoveResult(c.Hub, room, result)
 
-		c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-			return NewMessage(TypeGameOver, GameOverPayload{
-				Winner:       2,
-				WinningCells: winCells,
-				IsDraw:       false,
-			})
-		})
+	if result.GameOver {
+		publishGameCompleted(room)
 		return
 	}
 
-	// Check for draw
-	if room.Board.IsDraw() {
-		c.Hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
-			return NewMessage(TypeGameOver, GameOverPayload{
-				Winner: 0,
-				IsDraw: true,
-			})
-		})
+	// The human had no legal move and passed, so the bot moves again
+	if result.NextPlayer == 2 {
+		go c.makeBotMove(room)
 	}
 }
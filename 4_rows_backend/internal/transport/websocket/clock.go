@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+// timeControl reads the requested time control, the zero value for an untimed game
+func timeControl(msg IncomingMessage) game.TimeControl {
+	if msg.TimeControl == nil {
+		return game.TimeControl{}
+	}
+	return game.TimeControl{
+		Initial:   time.Duration(msg.TimeControl.InitialSeconds) * time.Second,
+		Increment: time.Duration(msg.TimeControl.IncrementSeconds) * time.Second,
+		PerMove:   time.Duration(msg.TimeControl.PerMoveSeconds) * time.Second,
+	}
+}
+
+// newTimeControlPayload describes a time control to clients, nil for an untimed game
+func newTimeControlPayload(tc game.TimeControl) *TimeControlPayload {
+	if !tc.Enabled() {
+		return nil
+	}
+	return &TimeControlPayload{
+		InitialSeconds:   int(tc.Initial / time.Second),
+		IncrementSeconds: int(tc.Increment / time.Second),
+		PerMoveSeconds:   int(tc.PerMove / time.Second),
+	}
+}
+
+func newClockPayload(clock *game.ClockSnapshot) *ClockPayload {
+	if clock == nil {
+		return nil
+	}
+	return &ClockPayload{
+		Player1Ms: clock.Remaining[0].Milliseconds(),
+		Player2Ms: clock.Remaining[1].Milliseconds(),
+		Running:   clock.Running,
+	}
+}
+
+// HandleClockTimeout tells a room that a player ran out of time and lost.
+// The room manager calls it from the flag timer.
+func HandleClockTimeout(room *game.Room, loser int) {
+	log.Printf("room %s: player %d ran out of time", room.Code, loser)
+
+	broadcastGameOver(GetHub(), room)
+	publishGameCompleted(room)
+}
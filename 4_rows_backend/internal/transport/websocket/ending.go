@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+
+	"4_rows_backend/internal/game"
+)
+
+// playerRoom returns the room the client plays in and their player number,
+// sending an error and returning nil if they are not a player anywhere
+func (c *Client) playerRoom() (*game.Room, int) {
+	roomCode := c.GetRoomCode()
+	if roomCode == "" {
+		c.SendJSON(NewError("not_in_room", "you are not in a room"))
+		return nil, 0
+	}
+	if c.IsSpectator() {
+		c.SendJSON(NewError("spectator", "spectators cannot take part in the game"))
+		return nil, 0
+	}
+
+	rm := game.GetRoomManager()
+	room := rm.GetRoom(roomCode)
+	if room == nil {
+		c.SendJSON(NewError("room_gone", "room no longer exists"))
+		return nil, 0
+	}
+
+	playerNum := rm.GetPlayerNumber(room, c.ID)
+	if playerNum == 0 {
+		c.SendJSON(NewError("not_player", "you are not a player in this room"))
+		return nil, 0
+	}
+	return room, playerNum
+}
+
+// endGameError reports a resign or draw message that does not fit the game's state
+func endGameError(err error) OutgoingMessage {
+	switch {
+	case errors.Is(err, game.ErrGameNotActive):
+		return NewError("game_not_active", err.Error())
+	case errors.Is(err, game.ErrDrawOffered):
+		return NewError("draw_already_offered", err.Error())
+	default:
+		return NewError("no_draw_offer", err.Error())
+	}
+}
+
+// endGame announces a game that ended without a move and records it
+func (c *Client) endGame(room *game.Room) {
+	game.GetRoomManager().SaveRoomState(room)
+	broadcastGameOver(c.Hub, room)
+	publishGameCompleted(room)
+}
+
+func (c *Client) handleResign() {
+	room, playerNum := c.playerRoom()
+	if room == nil {
+		return
+	}
+
+	if err := room.Resign(playerNum); err != nil {
+		c.SendJSON(endGameError(err))
+		return
+	}
+
+	log.Printf("room %s: player %d resigned", room.Code, playerNum)
+	c.endGame(room)
+}
+
+func (c *Client) handleOfferDraw() {
+	room, playerNum := c.playerRoom()
+	if room == nil {
+		return
+	}
+
+	agreed, err := room.OfferDraw(playerNum)
+	if err != nil {
+		c.SendJSON(endGameError(err))
+		return
+	}
+	if agreed {
+		log.Printf("room %s: draw agreed", room.Code)
+		c.endGame(room)
+		return
+	}
+
+	// The bot plays every game out
+	if room.IsBotGame {
+		room.DeclineDraw(2)
+		c.SendJSON(NewMessage(TypeDrawDeclined, DrawOfferPayload{PlayerNumber: 2}))
+		return
+	}
+
+	log.Printf("room %s: player %d offered a draw", room.Code, playerNum)
+	c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeDrawOffered, DrawOfferPayload{PlayerNumber: playerNum})
+	})
+}
+
+// handleAnswerDraw accepts or declines the draw the opponent offered
+func (c *Client) handleAnswerDraw(accept bool) {
+	room, playerNum := c.playerRoom()
+	if room == nil {
+		return
+	}
+
+	if !accept {
+		if err := room.DeclineDraw(playerNum); err != nil {
+			c.SendJSON(endGameError(err))
+			return
+		}
+		c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+			return NewMessage(TypeDrawDeclined, DrawOfferPayload{PlayerNumber: playerNum})
+		})
+		return
+	}
+
+	if err := room.AcceptDraw(playerNum); err != nil {
+		c.SendJSON(endGameError(err))
+		return
+	}
+
+	log.Printf("room %s: draw agreed", room.Code)
+	c.endGame(room)
+}
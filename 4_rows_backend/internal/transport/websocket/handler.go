@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 
+	"4_rows_backend/internal/accounts"
+
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -17,7 +19,24 @@ var upgrader = websocket.Upgrader{
 }
 
 func HandleWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	account, err := authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Guests keep their ID across connections through the guest token
+	var header http.Header
+	identity := IdentityPayload{}
+	if account != nil {
+		identity.PlayerID, identity.Username = account.ID, account.Username
+	} else {
+		identity.PlayerID, identity.GuestToken = guestIdentity(r)
+		identity.Guest = true
+		header = http.Header{"Set-Cookie": {accounts.GuestCookieFor(identity.GuestToken).String()}}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, header)
 	if err != nil {
 		log.Printf("upgrade error: %v", err)
 		return
@@ -26,11 +45,46 @@ func HandleWS(w http.ResponseWriter, r *http.Request) {
 	clientID := uuid.New().String()
 	hub := GetHub()
 	client := NewClient(clientID, conn, hub)
+	client.account = account
+	if identity.Guest {
+		client.guestID = identity.PlayerID
+	}
 
 	hub.Register(client)
 
-	log.Printf("new client connected: %s (total: %d)", clientID, hub.ClientCount())
+	if account != nil {
+		log.Printf("new client connected: %s as %s (total: %d)", clientID, account.Username, hub.ClientCount())
+	} else {
+		log.Printf("new client connected: %s (total: %d)", clientID, hub.ClientCount())
+	}
+
+	client.SendJSON(NewMessage(TypeIdentity, identity))
 
 	go client.ReadLoop()
 	go client.WriteLoop()
 }
+
+// authenticate returns the account whose session token came with the upgrade
+// request, or nil for a guest who sent none
+func authenticate(r *http.Request) (*accounts.Account, error) {
+	token := accounts.TokenFromRequest(r)
+	if token == "" {
+		return nil, nil
+	}
+	am := accounts.GetManager()
+	if am == nil {
+		return nil, accounts.ErrInvalidSession
+	}
+	return am.Authenticate(token)
+}
+
+// guestIdentity returns the guest ID and token of a guest's request, starting
+// a new guest if it has no valid token
+func guestIdentity(r *http.Request) (string, string) {
+	if token := accounts.GuestTokenFromRequest(r); token != "" {
+		if id, err := accounts.ParseGuestToken(token); err == nil {
+			return id, token
+		}
+	}
+	return accounts.NewGuest()
+}
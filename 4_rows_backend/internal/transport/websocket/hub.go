@@ -5,6 +5,7 @@ import "sync"
 type Hub struct {
 	clients map[*Client]bool
 	rooms   map[string]map[*Client]bool
+	lobby   map[*Client]bool // clients subscribed to lobby updates
 	mu      sync.RWMutex
 }
 
@@ -14,6 +15,7 @@ func NewHub() *Hub {
 	return &Hub{
 		clients: make(map[*Client]bool),
 		rooms:   make(map[string]map[*Client]bool),
+		lobby:   make(map[*Client]bool),
 	}
 }
 
@@ -36,6 +38,7 @@ func (h *Hub) Unregister(client *Client) {
 
 	if _, exists := h.clients[client]; exists {
 		delete(h.clients, client)
+		delete(h.lobby, client)
 
 		if client.RoomCode != "" {
 			if room, ok := h.rooms[client.RoomCode]; ok {
@@ -89,6 +92,54 @@ func (h *Hub) BroadcastToRoom(roomCode string, msgFunc func(*Client) OutgoingMes
 	}
 }
 
+// SubscribeLobby adds a client to the receivers of lobby updates
+func (h *Hub) SubscribeLobby(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lobby[client] = true
+}
+
+// UnsubscribeLobby stops lobby updates to a client
+func (h *Hub) UnsubscribeLobby(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.lobby, client)
+}
+
+// BroadcastToLobby sends a message to every client subscribed to the lobby
+func (h *Hub) BroadcastToLobby(msg OutgoingMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.lobby {
+		client.SendJSON(msg)
+	}
+}
+
+// SendToClient delivers a message only if the client has not been unregistered in the meantime,
+// for replies produced by background work
+func (h *Hub) SendToClient(client *Client, msg OutgoingMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.clients[client] {
+		client.SendJSON(msg)
+	}
+}
+
+// FindClient returns the connected client with the given ID, or nil
+func (h *Hub) FindClient(id string) *Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.ID == id {
+			return client
+		}
+	}
+	return nil
+}
+
 func (h *Hub) GetRoomClients(roomCode string) []*Client {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -102,6 +153,20 @@ func (h *Hub) GetRoomClients(roomCode string) []*Client {
 	return clients
 }
 
+// SpectatorCount returns how many clients watch a room without playing in it
+func (h *Hub) SpectatorCount(roomCode string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for client := range h.rooms[roomCode] {
+		if client.IsSpectator() {
+			count++
+		}
+	}
+	return count
+}
+
 func (h *Hub) ClientCount() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -0,0 +1,41 @@
+package websocket
+
+import "4_rows_backend/internal/game"
+
+// newLobbyRoomPayload describes an open room to lobby clients
+func newLobbyRoomPayload(room game.LobbyRoom) LobbyRoomPayload {
+	return LobbyRoomPayload{
+		RoomCode:    room.Code,
+		HostName:    room.Host,
+		Variant:     string(room.Variant),
+		Width:       room.Dimensions.Cols,
+		Height:      room.Dimensions.Rows,
+		Connect:     room.Dimensions.Connect,
+		Rated:       room.Rated,
+		TimeControl: newTimeControlPayload(room.TimeControl),
+		CreatedAt:   room.CreatedAt,
+	}
+}
+
+// handleSubscribeLobby sends the open public rooms and keeps the client
+// updated as rooms open and fill
+func (c *Client) handleSubscribeLobby() {
+	c.Hub.SubscribeLobby(c)
+
+	rooms := game.GetRoomManager().OpenRooms()
+	lobby := LobbyPayload{Rooms: make([]LobbyRoomPayload, len(rooms))}
+	for i, room := range rooms {
+		lobby.Rooms[i] = newLobbyRoomPayload(room)
+	}
+	c.SendJSON(NewMessage(TypeLobby, lobby))
+}
+
+// HandleLobbyUpdate tells lobby subscribers that a public room opened or is no
+// longer waiting for an opponent. The room manager calls it.
+func HandleLobbyUpdate(room game.LobbyRoom, open bool) {
+	if open {
+		GetHub().BroadcastToLobby(NewMessage(TypeLobbyRoomAdded, newLobbyRoomPayload(room)))
+		return
+	}
+	GetHub().BroadcastToLobby(NewMessage(TypeLobbyRoomRemoved, LobbyRoomRemovedPayload{RoomCode: room.Code}))
+}
@@ -0,0 +1,181 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"4_rows_backend/internal/bot"
+	"4_rows_backend/internal/game"
+)
+
+// handleFindMatch queues the client for an opponent of similar rating. The
+// game starts when the matchmaker pairs them, see HandleMatch.
+func (c *Client) handleFindMatch(msg IncomingMessage) {
+	if c.GetRoomCode() != "" && !c.IsSpectator() {
+		c.SendJSON(NewError("already_in_room", "you are already in a room"))
+		return
+	}
+	c.stopSpectating()
+
+	if msg.PlayerName == "" {
+		msg.PlayerName = "Player"
+	}
+	player := c.player(msg.PlayerName)
+	req := game.MatchRequest{
+		PlayerID:   player.ID,
+		PlayerName: player.Name,
+		AccountID:  player.AccountID,
+		GuestID:    player.GuestID,
+		Variant:    game.Variant(msg.Variant),
+	}
+	if msg.TimeControl != nil {
+		tc := timeControl(msg)
+		req.TimeControl = &tc
+	}
+
+	if err := req.Validate(); err != nil {
+		c.SendJSON(createRoomError(err))
+		return
+	}
+
+	// The rating lookup may be slow, so the client is queued once it is done
+	c.pendingMatch = &req
+	go c.queueForMatch(&req, player.Identity())
+}
+
+// queueForMatch looks up the rating of a player who asked for a match and
+// queues them, unless they cancelled, searched again or sat down meanwhile
+func (c *Client) queueForMatch(req *game.MatchRequest, identity string) {
+	mm := game.GetMatchmaker()
+	rating := mm.Rating(identity)
+
+	c.actions.Lock()
+	defer c.actions.Unlock()
+	if c.closed || c.pendingMatch != req {
+		return
+	}
+	c.pendingMatch = nil
+	if c.GetRoomCode() != "" {
+		return
+	}
+	req.Rating = rating
+
+	// Confirm the search before the matchmaker may pair the client
+	searching := MatchSearchingPayload{
+		Variant:            string(req.Variant),
+		Rating:             int(rating),
+		BotFallbackSeconds: int(mm.BotFallback() / time.Second),
+	}
+	if req.TimeControl != nil {
+		searching.TimeControl = newTimeControlPayload(*req.TimeControl)
+	}
+	c.SendJSON(NewMessage(TypeMatchSearching, searching))
+
+	if err := mm.FindMatch(*req); err != nil {
+		c.SendJSON(createRoomError(err))
+	}
+}
+
+func (c *Client) handleCancelMatch() {
+	pending := c.pendingMatch != nil
+	c.pendingMatch = nil
+	if !game.GetMatchmaker().Cancel(c.ID) && !pending {
+		c.SendJSON(NewError("not_searching", "you are not looking for a match"))
+		return
+	}
+	c.SendJSON(NewMessage(TypeMatchCancelled, nil))
+}
+
+// HandleMatch seats two matched players in their room and starts the game.
+// The matchmaker calls it for every match.
+func HandleMatch(match game.Match) {
+	hub := GetHub()
+	var found [2]*Client
+	for i, req := range match.Players {
+		found[i] = hub.FindClient(req.PlayerID)
+	}
+
+	// Seat the players like messages from them, so neither can sit down
+	// somewhere else meanwhile
+	unlock := lockActions(found[0], found[1])
+	defer unlock()
+
+	var clients [2]*Client
+	for i, client := range found {
+		if client != nil && !client.closed && client.GetRoomCode() == "" {
+			clients[i] = client
+		}
+	}
+
+	// A player may have gone in the meantime; the other one goes back in the queue
+	if clients[0] == nil || clients[1] == nil {
+		game.GetRoomManager().RemoveRoom(match.Room.Code)
+		for i, client := range clients {
+			if client != nil {
+				game.GetMatchmaker().FindMatch(match.Players[i])
+			}
+		}
+		return
+	}
+
+	for _, client := range clients {
+		client.SetRoomCode(match.Room.Code)
+		hub.JoinRoom(match.Room.Code, client)
+	}
+	log.Printf("matched %s (%.0f) and %s (%.0f) in room %s",
+		match.Players[0].PlayerName, match.Players[0].Rating,
+		match.Players[1].PlayerName, match.Players[1].Rating, match.Room.Code)
+
+	broadcastGameStart(hub, match.Room)
+}
+
+// lockActions locks the actions of two clients in ID order, so two
+// goroutines locking the same pair cannot deadlock. Either may be nil.
+func lockActions(a, b *Client) (unlock func()) {
+	if a == b {
+		b = nil
+	} else if a != nil && b != nil && b.ID < a.ID {
+		a, b = b, a
+	}
+	for _, c := range []*Client{a, b} {
+		if c != nil {
+			c.actions.Lock()
+		}
+	}
+	return func() {
+		for _, c := range []*Client{b, a} {
+			if c != nil {
+				c.actions.Unlock()
+			}
+		}
+	}
+}
+
+// HandleMatchFallback starts a bot game for a player nobody was matched with
+// in time, against a bot of about their strength. The matchmaker calls it
+// from the fallback timer.
+func HandleMatchFallback(req game.MatchRequest) {
+	mm := game.GetMatchmaker()
+	c := GetHub().FindClient(req.PlayerID)
+	if c == nil {
+		mm.TakeFallback(req)
+		return
+	}
+
+	// Handle the fallback like a message from the client, so nothing the
+	// client sends meanwhile can seat it somewhere else
+	c.actions.Lock()
+	defer c.actions.Unlock()
+	if !mm.TakeFallback(req) || c.closed || c.GetRoomCode() != "" {
+		return
+	}
+
+	difficulty := bot.DifficultyForRating(req.Rating)
+	log.Printf("no match found for client %s, starting a %s bot game", c.ID, difficulty)
+
+	opts := game.RoomOptions{Variant: req.Variant}
+	if req.TimeControl != nil {
+		opts.TimeControl = *req.TimeControl
+	}
+	c.handleCreateBotGame(req.PlayerName, string(difficulty), opts)
+}
@@ -1,5 +1,7 @@
 package websocket
 
+import "time"
+
 type MessageType string
 
 const (
@@ -9,6 +11,7 @@ const (
 	TypeRoomJoined      MessageType = "room_joined"
 	TypeGameStart       MessageType = "game_start"
 	TypeMove            MessageType = "move"
+	TypePop             MessageType = "pop"
 	TypeMoveResult      MessageType = "move_result"
 	TypeGameOver        MessageType = "game_over"
 	TypeRematchRequest  MessageType = "rematch_request"
@@ -20,13 +23,88 @@ const (
 	TypePong            MessageType = "pong"
 	TypeCreateBotGame   MessageType = "create_bot_game"
 	TypeBotMove         MessageType = "bot_move"
+	TypeResume          MessageType = "resume"
+	TypeResumed         MessageType = "resumed"
+
+	TypeOpponentDisconnected MessageType = "opponent_disconnected"
+	TypeOpponentReconnected  MessageType = "opponent_reconnected"
+
+	TypeRequestState MessageType = "request_state"
+	TypeStateSync    MessageType = "state_sync"
+
+	TypeRequestHint     MessageType = "request_hint"
+	TypeAnalyzePosition MessageType = "analyze_position"
+	TypeHint            MessageType = "hint"
+	TypeAnalysis        MessageType = "analysis"
+
+	TypeResign       MessageType = "resign"
+	TypeOfferDraw    MessageType = "offer_draw"
+	TypeAcceptDraw   MessageType = "accept_draw"
+	TypeDeclineDraw  MessageType = "decline_draw"
+	TypeDrawOffered  MessageType = "draw_offered"
+	TypeDrawDeclined MessageType = "draw_declined"
+
+	TypeRequestTakeback  MessageType = "request_takeback"
+	TypeAcceptTakeback   MessageType = "accept_takeback"
+	TypeDeclineTakeback  MessageType = "decline_takeback"
+	TypeTakebackRequest  MessageType = "takeback_requested"
+	TypeTakebackDeclined MessageType = "takeback_declined"
+	TypeTakeback         MessageType = "takeback"
+
+	TypeWatchReplay MessageType = "watch_replay"
+	TypeReplaySpeed MessageType = "replay_speed"
+	TypeStopReplay  MessageType = "stop_replay"
+	TypeReplayStart MessageType = "replay_start"
+
+	TypeSpectateRoom   MessageType = "spectate_room"
+	TypeStopSpectating MessageType = "stop_spectating"
+	TypeSpectatorCount MessageType = "spectator_count"
+
+	TypeChat        MessageType = "chat"
+	TypeEmote       MessageType = "emote"
+	TypeChatMessage MessageType = "chat_message"
+	TypeMuteChat    MessageType = "mute_chat"
+	TypeChatMuted   MessageType = "chat_muted"
+
+	TypeFindMatch      MessageType = "find_match"
+	TypeCancelMatch    MessageType = "cancel_match"
+	TypeMatchSearching MessageType = "match_searching"
+	TypeMatchCancelled MessageType = "match_cancelled"
+
+	TypeSubscribeLobby   MessageType = "subscribe_lobby"
+	TypeUnsubscribeLobby MessageType = "unsubscribe_lobby"
+	TypeLobby            MessageType = "lobby"
+	TypeLobbyRoomAdded   MessageType = "lobby_room_added"
+	TypeLobbyRoomRemoved MessageType = "lobby_room_removed"
+
+	TypeIdentity MessageType = "identity"
 )
 
 type IncomingMessage struct {
-	Type       MessageType `json:"type"`
-	RoomCode   string      `json:"room_code,omitempty"`
-	Column     int         `json:"column,omitempty"`
-	PlayerName string      `json:"player_name,omitempty"`
+	Type        MessageType `json:"type"`
+	RoomCode    string      `json:"room_code,omitempty"`
+	Column      int         `json:"column,omitempty"`
+	PlayerName  string      `json:"player_name,omitempty"`
+	ResumeToken string      `json:"resume_token,omitempty"`
+	Difficulty  string      `json:"difficulty,omitempty"`
+	BotFirst    bool        `json:"bot_first,omitempty"` // the bot makes the first move
+	Rated       bool        `json:"rated,omitempty"`
+	Public      bool        `json:"public,omitempty"`
+	Width       int         `json:"width,omitempty"`
+	Height      int         `json:"height,omitempty"`
+	Connect     int         `json:"connect,omitempty"`
+	Variant     string      `json:"variant,omitempty"`
+
+	TimeControl *TimeControlPayload `json:"time_control,omitempty"`
+
+	Position string `json:"position,omitempty"` // start from this position notation instead of the variant's start
+
+	Text  string `json:"text,omitempty"`
+	Emote string `json:"emote,omitempty"`
+	Muted bool   `json:"muted,omitempty"`
+
+	GameID string  `json:"game_id,omitempty"`
+	Speed  float64 `json:"speed,omitempty"` // replay speed, 1 plays a move per second
 }
 
 type OutgoingMessage struct {
@@ -34,8 +112,19 @@ type OutgoingMessage struct {
 	Payload interface{} `json:"payload,omitempty"`
 }
 
+// IdentityPayload is sent on connect and tells the client who its games are
+// attributed to. Guests get a guest token to present on later connections,
+// as the guest query parameter or the guest_token cookie.
+type IdentityPayload struct {
+	PlayerID   string `json:"player_id"`
+	Username   string `json:"username,omitempty"`
+	Guest      bool   `json:"guest"`
+	GuestToken string `json:"guest_token,omitempty"`
+}
+
 type RoomCreatedPayload struct {
-	RoomCode string `json:"room_code"`
+	RoomCode    string `json:"room_code"`
+	ResumeToken string `json:"resume_token"`
 }
 
 type RoomJoinedPayload struct {
@@ -44,24 +133,149 @@ type RoomJoinedPayload struct {
 }
 
 type GameStartPayload struct {
+	RoomCode      string `json:"room_code"`
+	PlayerNumber  int    `json:"player_number"`
+	Player1Name   string `json:"player1_name"`
+	Player2Name   string `json:"player2_name"`
+	ResumeToken   string `json:"resume_token"`
+	BotDifficulty string `json:"bot_difficulty,omitempty"`
+	Rated         bool   `json:"rated"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	Connect       int    `json:"connect"`
+	Variant       string `json:"variant"`
+	StartPosition string `json:"start_position,omitempty"` // seeded position in position notation
+
+	TimeControl *TimeControlPayload `json:"time_control,omitempty"`
+}
+
+type ResumedPayload struct {
 	RoomCode     string `json:"room_code"`
 	PlayerNumber int    `json:"player_number"`
 	Player1Name  string `json:"player1_name"`
 	Player2Name  string `json:"player2_name"`
+	ResumeToken  string `json:"resume_token"`
+}
+
+type OpponentDisconnectedPayload struct {
+	GraceSeconds int `json:"grace_seconds"`
+}
+
+type SpectatorCountPayload struct {
+	Count int `json:"count"`
+}
+
+type ChatPayload struct {
+	Kind         string    `json:"kind"`    // "chat" or "emote"
+	Channel      string    `json:"channel"` // "room" or "spectators"
+	Sender       string    `json:"sender"`
+	PlayerNumber int       `json:"player_number"` // 0 for spectators
+	Text         string    `json:"text"`          // the message, or the emote's name
+	SentAt       time.Time `json:"sent_at"`
+}
+
+type ChatMutedPayload struct {
+	Muted bool `json:"muted"`
+}
+
+// LobbyRoomPayload is a public room waiting for an opponent
+type LobbyRoomPayload struct {
+	RoomCode    string              `json:"room_code"`
+	HostName    string              `json:"host_name"`
+	Variant     string              `json:"variant"`
+	Width       int                 `json:"width"`
+	Height      int                 `json:"height"`
+	Connect     int                 `json:"connect"`
+	Rated       bool                `json:"rated"`
+	TimeControl *TimeControlPayload `json:"time_control,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type MatchSearchingPayload struct {
+	Variant            string              `json:"variant,omitempty"` // empty when any variant will do
+	TimeControl        *TimeControlPayload `json:"time_control,omitempty"`
+	Rating             int                 `json:"rating"`
+	BotFallbackSeconds int                 `json:"bot_fallback_seconds,omitempty"` // 0 if the search never falls back to the bot
+}
+
+type LobbyPayload struct {
+	Rooms []LobbyRoomPayload `json:"rooms"`
+}
+
+type LobbyRoomRemovedPayload struct {
+	RoomCode string `json:"room_code"`
 }
 
 type MoveResultPayload struct {
-	Column       int  `json:"column"`
-	Row          int  `json:"row"`
-	PlayerNumber int  `json:"player_number"`
-	NextPlayer   int  `json:"next_player"`
-	Valid        bool `json:"valid"`
+	Action       string `json:"action"` // "drop" or "pop"
+	Kept         bool   `json:"kept,omitempty"`
+	Column       int    `json:"column"`
+	Row          int    `json:"row"`
+	PlayerNumber int    `json:"player_number"`
+	NextPlayer   int    `json:"next_player"`
+	Valid        bool   `json:"valid"`
+
+	Clock *ClockPayload `json:"clock,omitempty"`
 }
 
 type GameOverPayload struct {
+	GameID       string         `json:"game_id,omitempty"` // archived game, see GET /api/games/{id}
 	Winner       int            `json:"winner"`
 	WinningCells []CellPosition `json:"winning_cells,omitempty"`
 	IsDraw       bool           `json:"is_draw"`
+	Reason       string         `json:"reason"` // connect, board_full, repetition, resign, agreement, timeout or abandonment
+}
+
+// DrawOfferPayload names the player who offered or declined a draw
+type DrawOfferPayload struct {
+	PlayerNumber int `json:"player_number"`
+}
+
+// TakebackRequestPayload names the player who asked for a takeback or turned one down
+type TakebackRequestPayload struct {
+	PlayerNumber int `json:"player_number"`
+}
+
+// TakebackPayload reports moves that were taken back and the position they leave
+type TakebackPayload struct {
+	PlayerNumber int           `json:"player_number"` // player who asked for the takeback
+	Undone       []MoveRecord  `json:"undone"`        // most recent first
+	Board        [][]int       `json:"board"`
+	CurrentTurn  int           `json:"current_turn"`
+	Clock        *ClockPayload `json:"clock,omitempty"`
+}
+
+// ReplayStartPayload describes an archived game before its moves are streamed as move_result messages
+type ReplayStartPayload struct {
+	GameID      string    `json:"game_id"`
+	RoomCode    string    `json:"room_code"`
+	Player1Name string    `json:"player1_name"`
+	Player2Name string    `json:"player2_name"`
+	Board       [][]int   `json:"board"` // starting position
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Connect     int       `json:"connect"`
+	Variant     string    `json:"variant"`
+	Rated       bool      `json:"rated"`
+	MoveCount   int       `json:"move_count"`
+	Speed       float64   `json:"speed"`
+	StartedAt   time.Time `json:"started_at"`
+	EndedAt     time.Time `json:"ended_at"`
+}
+
+// TimeControlPayload is a room's time control: either a game budget plus an
+// increment per move, or a fixed limit per move
+type TimeControlPayload struct {
+	InitialSeconds   int `json:"initial_seconds,omitempty"`
+	IncrementSeconds int `json:"increment_seconds,omitempty"`
+	PerMoveSeconds   int `json:"per_move_seconds,omitempty"`
+}
+
+// ClockPayload is the time each player has left when the message was sent
+type ClockPayload struct {
+	Player1Ms int64 `json:"player1_ms"`
+	Player2Ms int64 `json:"player2_ms"`
+	Running   int   `json:"running"` // player whose clock is running, 0 while stopped
 }
 
 type CellPosition struct {
@@ -69,6 +283,70 @@ type CellPosition struct {
 	Col int `json:"col"`
 }
 
+type MoveRecord struct {
+	Action       string    `json:"action"`
+	Kept         bool      `json:"kept,omitempty"`
+	Column       int       `json:"column"`
+	Row          int       `json:"row"`
+	PlayerNumber int       `json:"player_number"`
+	PlayedAt     time.Time `json:"played_at"`
+}
+
+type StateSyncPayload struct {
+	RoomCode        string         `json:"room_code"`
+	GameID          string         `json:"game_id"`
+	PlayerNumber    int            `json:"player_number"`
+	Board           [][]int        `json:"board"`
+	Width           int            `json:"width"`
+	Height          int            `json:"height"`
+	Connect         int            `json:"connect"`
+	Variant         string         `json:"variant"`
+	Kept            [2]int         `json:"kept"` // discs kept by each player in Pop Ten
+	CurrentTurn     int            `json:"current_turn"`
+	Player1Name     string         `json:"player1_name"`
+	Player2Name     string         `json:"player2_name"`
+	Moves           []MoveRecord   `json:"moves"`
+	MoveSequence    string         `json:"move_sequence"` // moves in move notation, e.g. "4453"
+	Position        string         `json:"position"`      // current position in position notation
+	GameStarted     bool           `json:"game_started"`
+	GameOver        bool           `json:"game_over"`
+	Winner          int            `json:"winner"`
+	IsDraw          bool           `json:"is_draw"`
+	WinningCells    []CellPosition `json:"winning_cells,omitempty"`
+	RematchRequests [2]bool        `json:"rematch_requests"`
+	Reason          string         `json:"reason,omitempty"`
+	DrawOfferedBy   int            `json:"draw_offered_by,omitempty"`
+	TakebackBy      int            `json:"takeback_requested_by,omitempty"`
+	Spectators      int            `json:"spectators"`
+	Chat            []ChatPayload  `json:"chat"`
+
+	TimeControl *TimeControlPayload `json:"time_control,omitempty"`
+	Clock       *ClockPayload       `json:"clock,omitempty"`
+}
+
+type HintPayload struct {
+	Action  string `json:"action"`
+	Column  int    `json:"column"`
+	Outcome string `json:"outcome"`
+}
+
+type ColumnScore struct {
+	Column    int    `json:"column"`
+	Playable  bool   `json:"playable"`
+	Outcome   string `json:"outcome,omitempty"`
+	Score     int    `json:"score"`
+	MovesLeft int    `json:"moves_left,omitempty"`
+}
+
+type AnalysisPayload struct {
+	PlayerNumber int           `json:"player_number"`
+	BestMove     int           `json:"best_move"`
+	BestAction   string        `json:"best_action"`
+	Exact        bool          `json:"exact"`
+	Columns      []ColumnScore `json:"columns"`
+	Pops         []ColumnScore `json:"pops,omitempty"`
+}
+
 type RematchWaitingPayload struct {
 	Message     string `json:"message"`
 	IsInitiator bool   `json:"is_initiator"`
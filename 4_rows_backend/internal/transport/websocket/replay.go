@@ -0,0 +1,160 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"4_rows_backend/internal/game"
+)
+
+const (
+	// replayInterval is the time between two moves of a replay at speed 1
+	replayInterval = time.Second
+
+	MinReplaySpeed = 0.25
+	MaxReplaySpeed = 16.0
+)
+
+// replaySession streams one archived game to a client
+type replaySession struct {
+	stop     chan struct{}
+	interval atomic.Int64 // time.Duration between moves
+}
+
+func (s *replaySession) setSpeed(speed float64) {
+	s.interval.Store(int64(float64(replayInterval) / speed))
+}
+
+func (s *replaySession) delay() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// replaySpeed clamps a requested speed, treating a missing one as real speed 1
+func replaySpeed(speed float64) float64 {
+	if speed == 0 {
+		return 1
+	}
+	return min(max(speed, MinReplaySpeed), MaxReplaySpeed)
+}
+
+// handleWatchReplay plays an archived game back to the client as move_result
+// messages, ending with its game_over. Watching is only possible outside a room.
+func (c *Client) handleWatchReplay(gameID string, speed float64) {
+	if gameID == "" {
+		c.SendJSON(NewError("missing_game_id", "game id is required"))
+		return
+	}
+	if c.GetRoomCode() != "" {
+		c.SendJSON(NewError("already_in_room", "leave your room to watch a replay"))
+		return
+	}
+
+	record, err := game.GetRoomManager().GetGameRecord(gameID)
+	if errors.Is(err, game.ErrGameNotFound) {
+		c.SendJSON(NewError("game_not_found", err.Error()))
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading game %s: %v", gameID, err)
+		c.SendJSON(NewError("replay_failed", "could not load the game"))
+		return
+	}
+
+	c.stopReplay()
+	session := &replaySession{stop: make(chan struct{})}
+	session.setSpeed(replaySpeed(speed))
+	c.mu.Lock()
+	c.replay = session
+	c.mu.Unlock()
+
+	start := record.StartingPosition()
+	c.SendJSON(NewMessage(TypeReplayStart, ReplayStartPayload{
+		GameID:      record.ID,
+		RoomCode:    record.RoomCode,
+		Player1Name: record.Players[0],
+		Player2Name: record.Players[1],
+		Board:       start.Board.Grid(),
+		Width:       record.Dimensions.Cols,
+		Height:      record.Dimensions.Rows,
+		Connect:     record.Dimensions.Connect,
+		Variant:     string(record.Variant),
+		Rated:       record.Rated,
+		MoveCount:   len(record.Moves),
+		Speed:       replaySpeed(speed),
+		StartedAt:   record.StartedAt,
+		EndedAt:     record.EndedAt,
+	}))
+
+	go c.playReplay(record, start, session)
+}
+
+// handleReplaySpeed changes the speed of the replay being watched
+func (c *Client) handleReplaySpeed(speed float64) {
+	c.mu.Lock()
+	session := c.replay
+	c.mu.Unlock()
+
+	if session == nil {
+		c.SendJSON(NewError("no_replay", "you are not watching a replay"))
+		return
+	}
+	session.setSpeed(replaySpeed(speed))
+}
+
+// stopReplay ends the replay being watched, if any
+func (c *Client) stopReplay() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.replay != nil {
+		close(c.replay.stop)
+		c.replay = nil
+	}
+}
+
+// playReplay replays the moves from the start so every move_result carries the
+// real next player and the final game_over the winning line
+func (c *Client) playReplay(record *game.GameRecord, start game.Position, session *replaySession) {
+	defer func() {
+		c.mu.Lock()
+		if c.replay == session {
+			c.replay = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	rules := game.RulesFor(record.Variant)
+	board, state := start.Board, start.RuleState()
+	var winningCells []game.CellPos
+
+	timer := time.NewTimer(session.delay())
+	defer timer.Stop()
+
+	for _, move := range record.Moves {
+		select {
+		case <-session.stop:
+			return
+		case <-timer.C:
+		}
+
+		result, err := game.PlayMove(rules, &board, &state, move)
+		if err != nil {
+			log.Printf("game %s: archived move does not replay: %v", record.ID, err)
+			c.Hub.SendToClient(c, NewError("replay_failed", "the archived game could not be replayed"))
+			return
+		}
+		winningCells = result.WinningCells
+
+		c.Hub.SendToClient(c, NewMessage(TypeMoveResult, newMoveResultPayload(result.Move, result.NextPlayer)))
+		timer.Reset(session.delay())
+	}
+
+	select {
+	case <-session.stop:
+		return
+	case <-timer.C:
+	}
+	c.Hub.SendToClient(c, NewMessage(TypeGameOver, newGameOverPayload(record.ID, record.Winner, record.Reason, winningCells)))
+}
@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"log"
+
+	"4_rows_backend/internal/game"
+)
+
+// handleSpectate attaches the client to a room as a read-only spectator. The
+// client gets a full snapshot now and the room's broadcasts from then on.
+func (c *Client) handleSpectate(code string) {
+	if code == "" {
+		c.SendJSON(NewError("missing_code", "room code is required"))
+		return
+	}
+	if c.GetRoomCode() != "" && !c.IsSpectator() {
+		c.SendJSON(NewError("already_in_room", "you are already in a room"))
+		return
+	}
+
+	room := game.GetRoomManager().GetRoom(code)
+	if room == nil {
+		c.SendJSON(NewError("room_not_found", "room does not exist"))
+		return
+	}
+
+	c.stopSpectating()
+
+	c.mu.Lock()
+	c.RoomCode = room.Code
+	c.watching = true
+	c.mu.Unlock()
+	c.Hub.JoinRoom(room.Code, c)
+
+	log.Printf("client %s is spectating room %s", c.ID, room.Code)
+
+	c.sendStateSync(room)
+	broadcastSpectatorCount(c.Hub, room.Code)
+}
+
+// stopSpectating detaches the client from the room it watches, if any
+func (c *Client) stopSpectating() {
+	c.mu.Lock()
+	roomCode := ""
+	if c.watching {
+		roomCode = c.RoomCode
+		c.RoomCode = ""
+		c.watching = false
+	}
+	c.mu.Unlock()
+
+	if roomCode == "" {
+		return
+	}
+	c.Hub.LeaveRoom(roomCode, c)
+	broadcastSpectatorCount(c.Hub, roomCode)
+}
+
+// IsSpectator reports whether the client watches its room rather than plays in it
+func (c *Client) IsSpectator() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.watching
+}
+
+// broadcastSpectatorCount tells everyone in a room how many spectators are watching
+func broadcastSpectatorCount(hub *Hub, roomCode string) {
+	count := SpectatorCountPayload{Count: hub.SpectatorCount(roomCode)}
+	hub.BroadcastToRoom(roomCode, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeSpectatorCount, count)
+	})
+}
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+
+	"4_rows_backend/internal/game"
+)
+
+// takebackError reports a takeback message that does not fit the game's state
+func takebackError(err error) OutgoingMessage {
+	switch {
+	case errors.Is(err, game.ErrTakebackNotAllowed):
+		return NewError("takeback_disabled", err.Error())
+	case errors.Is(err, game.ErrGameNotActive):
+		return NewError("game_not_active", err.Error())
+	case errors.Is(err, game.ErrNothingToTakeBack):
+		return NewError("nothing_to_take_back", err.Error())
+	case errors.Is(err, game.ErrTakebackRequested):
+		return NewError("takeback_already_requested", err.Error())
+	case errors.Is(err, game.ErrNoTakebackRequest):
+		return NewError("no_takeback_request", err.Error())
+	default:
+		return NewError("takeback_failed", err.Error())
+	}
+}
+
+func (c *Client) handleRequestTakeback() {
+	room, playerNum := c.playerRoom()
+	if room == nil {
+		return
+	}
+
+	undone, err := room.RequestTakeback(playerNum)
+	if err != nil {
+		c.SendJSON(takebackError(err))
+		return
+	}
+
+	if undone != nil {
+		// Bot games take moves back without asking
+		c.broadcastTakeback(room, playerNum, undone)
+		return
+	}
+
+	log.Printf("room %s: player %d requested a takeback", room.Code, playerNum)
+	c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeTakebackRequest, TakebackRequestPayload{PlayerNumber: playerNum})
+	})
+}
+
+// handleAnswerTakeback accepts or declines the takeback the opponent asked for
+func (c *Client) handleAnswerTakeback(accept bool) {
+	room, playerNum := c.playerRoom()
+	if room == nil {
+		return
+	}
+
+	if !accept {
+		if err := room.DeclineTakeback(playerNum); err != nil {
+			c.SendJSON(takebackError(err))
+			return
+		}
+		c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+			return NewMessage(TypeTakebackDeclined, TakebackRequestPayload{PlayerNumber: playerNum})
+		})
+		return
+	}
+
+	undone, err := room.AcceptTakeback(playerNum)
+	if err != nil {
+		c.SendJSON(takebackError(err))
+		return
+	}
+	c.broadcastTakeback(room, 3-playerNum, undone)
+}
+
+// broadcastTakeback saves the position after a takeback and sends it to the room
+func (c *Client) broadcastTakeback(room *game.Room, requester int, undone []game.Move) {
+	game.GetRoomManager().SaveRoomState(room)
+	log.Printf("room %s: took back %d moves for player %d", room.Code, len(undone), requester)
+
+	state := room.State()
+	payload := TakebackPayload{
+		PlayerNumber: requester,
+		Undone:       make([]MoveRecord, len(undone)),
+		Board:        state.Board,
+		CurrentTurn:  state.CurrentTurn,
+		Clock:        newClockPayload(state.Clock),
+	}
+	for i, move := range undone {
+		payload.Undone[i] = newMoveRecord(move)
+	}
+
+	c.Hub.BroadcastToRoom(room.Code, func(client *Client) OutgoingMessage {
+		return NewMessage(TypeTakeback, payload)
+	})
+}